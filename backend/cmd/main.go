@@ -1,15 +1,17 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"dealer-backend/internal/auth"
 	"dealer-backend/internal/config"
 	"dealer-backend/internal/handlers"
 	"dealer-backend/internal/middlewares"
+	"dealer-backend/internal/protocol"
+	"dealer-backend/internal/services"
 
 	"github.com/gorilla/websocket"
 )
@@ -53,35 +55,66 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
         }	  
 	}
 
-	// Parse the token message
-	var tokenData struct {
-		Token string `json:"token"`
-	}
-
-	// Use the tokenMsg (received in the loop) for parsing
-	if err := json.Unmarshal(tokenMsg, &tokenData); err != nil {
+	// Parse the hello message and agree on a protocol version
+	hello, err := protocol.DecodeHello(tokenMsg)
+	if err != nil {
 		fmt.Println("Error parsing token message:", err)
-		conn.Close()
+		refuse(conn, protocol.ErrorReply(err))
+		return
+	}
+	version, err := protocol.Negotiate(hello.Version)
+	if err != nil {
+		fmt.Println("Refusing connection:", err)
+		refuse(conn, protocol.Error{Code: protocol.CodeUnsupportedVersion, Message: err.Error(), Type: protocol.TypeHello})
 		return
 	}
 
 	// Extract username (playerID) from the token
-	username, err := auth.GetUsernameFromToken(tokenData.Token)
+	username, err := auth.GetUsernameFromToken(hello.Token)
 	fmt.Println("Username from token: ", username)
 	if err != nil {
 		fmt.Println("Error extracting username from token:", err)
+		refuse(conn, protocol.Error{Code: protocol.CodeUnauthorized, Message: err.Error(), Type: protocol.TypeHello})
+		return
+	}
+	if err := writeHandshake(conn, protocol.Welcome{Version: version, PlayerID: username}); err != nil {
+		fmt.Println("Error sending welcome:", err)
 		conn.Close()
 		return
 	}
 
+	// From here on only the client writes to the connection
+	client := services.NewClient(username, conn)
+
+	// A player with a seat in a running game goes straight back to it
+	if services.ResumePlayer(client) {
+		return
+	}
+
 	// Add the player connection to the map
-	config.PlayerConnections.AddPlayer(username, conn)
+	config.PlayerConnections.AddPlayer(client)
 
 	// Broadcast that the user has joined
-	config.PlayerConnections.BroadcastMessage("message", username+" joined")
+	config.PlayerConnections.BroadcastMessage(protocol.Notice(username + " joined"))
+}
+
+
+// writeHandshake sends a message of the handshake, which is outside any
+// message sequence
+func writeHandshake(conn *websocket.Conn, msg protocol.Message) error {
+	data, err := protocol.Encode(0, msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
 
-	// Optionally start matchmaking or other services
-	// services.StartMatchmaking(playerConnections, username)
+// refuse tells the client why it can't connect and closes the connection
+func refuse(conn *websocket.Conn, reason protocol.Error) {
+	if err := writeHandshake(conn, reason); err != nil {
+		fmt.Println("Error sending handshake error:", err)
+	}
+	conn.Close()
 }
 
 
@@ -92,6 +125,14 @@ func homePage(w http.ResponseWriter, r *http.Request) {
 
 // Main function to start the web server
 func main() {
+    // Keep the event log of every finished game when a directory is given
+    services.GameLogDir = os.Getenv("GAME_LOG_DIR")
+
+    // Keep ratings between restarts when a file is given
+    if err := services.Ratings.Load(os.Getenv("RATINGS_FILE")); err != nil {
+        log.Fatalf("Error loading ratings: %v", err)
+    }
+
     // Define routes
     http.HandleFunc("/", homePage)
     http.HandleFunc("/protected", handlers.ProtectedHandler)
@@ -109,8 +150,18 @@ func main() {
 
     //Game handler
     http.Handle("/game/start", auth.ValidateJWTMiddleware(http.HandlerFunc(handlers.StartHandler)))
+    // Cards are played over the WebSocket (playcard), where the player is known
+
+    //Private room handlers, players are told of changes over the WebSocket
+    http.Handle("POST /rooms", auth.ValidateJWTMiddleware(http.HandlerFunc(handlers.CreateRoomHandler)))
+    http.Handle("POST /rooms/{code}/join", auth.ValidateJWTMiddleware(http.HandlerFunc(handlers.JoinRoomHandler)))
+    http.Handle("PUT /rooms/{code}/settings", auth.ValidateJWTMiddleware(http.HandlerFunc(handlers.ConfigureRoomHandler)))
+    http.Handle("POST /rooms/{code}/leave", auth.ValidateJWTMiddleware(http.HandlerFunc(handlers.LeaveRoomHandler)))
+    http.Handle("POST /rooms/{code}/start", auth.ValidateJWTMiddleware(http.HandlerFunc(handlers.StartRoomHandler)))
+
+    //Ratings handler
+    http.HandleFunc("GET /players/{playerID}/rating", handlers.RatingHandler)
 
-    http.HandleFunc("/game/move", handlers.MoveHandler)
     //http.HandleFunc("/game/draw", handlers.DrawHandler)
     //http.HandleFunc("/game/status/", handlers.GameStatusHandler)
     //http.HandleFunc("/game/result/",handlers.ResultHandler)
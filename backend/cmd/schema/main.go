@@ -0,0 +1,32 @@
+// Command schema writes the JSON Schema of the WebSocket protocol, for the
+// frontend to validate messages against.
+//
+//	go run ./cmd/schema -out ../frontend/src/protocol/schema.json
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"dealer-backend/internal/protocol"
+)
+
+func main() {
+	out := flag.String("out", "", "file to write the schema to, stdout when empty")
+	flag.Parse()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("schema: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := protocol.WriteSchema(w); err != nil {
+		log.Fatalf("schema: %v", err)
+	}
+}
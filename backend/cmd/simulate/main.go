@@ -0,0 +1,172 @@
+// Command simulate plays bot-vs-bot Call Break matches in-process and prints
+// aggregate statistics per strategy.
+//
+//	go run ./cmd/simulate -games 5000 -bots hard,medium,easy,easy -format csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/models"
+)
+
+// config is what a simulation run is asked to do
+type config struct {
+	games    int
+	levels   []bot.Level // One per seat
+	numDeals int
+	rules    models.RuleSet
+	dealing  models.Dealing
+	seed     int64
+	budget   bot.Budget
+	workers  int
+}
+
+func main() {
+	var (
+		games    = flag.Int("games", 1000, "number of matches to play")
+		bots     = flag.String("bots", "hard,medium,easy,easy", "comma separated bot levels, one per seat (2-6)")
+		numDeals = flag.Int("deals", models.DefaultNumDeals, "deals per match")
+		variant  = flag.String("rules", models.StrictCallBreak.Variant, "rule variant")
+		deck     = flag.String("deck", models.StandardDeck.Name, "deck composition")
+		packet   = flag.Int("packet", 0, "cards per packet when dealing, 0 deals round-robin")
+		seed     = flag.Int64("seed", 1, "seed for deals and bots")
+		samples  = flag.Int("samples", 16, "deals sampled per decision by hard bots")
+		budget   = flag.Duration("budget", 0, "time limit per decision for hard bots, 0 keeps runs repeatable")
+		workers  = flag.Int("workers", runtime.NumCPU(), "matches played in parallel")
+		format   = flag.String("format", "json", "output format, json or csv")
+		out      = flag.String("out", "", "file to write the report to, stdout when empty")
+	)
+	flag.Parse()
+
+	write, ok := reportWriters[*format]
+	if !ok {
+		log.Fatalf("simulate: unknown format %q", *format)
+	}
+	cfg, err := newConfig(*games, *bots, *numDeals, *variant, *deck, *packet, *seed, *samples, *budget, *workers)
+	if err != nil {
+		log.Fatalf("simulate: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("simulate: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	started := time.Now()
+	report := run(cfg)
+	log.Printf("played %d matches in %s\n", report.Games, time.Since(started).Round(time.Millisecond))
+
+	if err := write(w, report); err != nil {
+		log.Fatalf("simulate: %v", err)
+	}
+	if report.Errors > 0 {
+		log.Printf("simulate: %d matches were aborted by the rules engine, first: %s\n", report.Errors, report.FirstError)
+		os.Exit(1)
+	}
+}
+
+func newConfig(games int, bots string, numDeals int, variant, deck string, packet int, seed int64, samples int, budget time.Duration, workers int) (config, error) {
+	cfg := config{
+		games:    games,
+		numDeals: numDeals,
+		dealing:  models.Dealing{Deck: deck, Packet: packet},
+		seed:     seed,
+		budget:   bot.Budget{Samples: samples, Time: budget},
+		workers:  workers,
+	}
+
+	for _, name := range strings.Split(bots, ",") {
+		level, ok := bot.Levels[strings.TrimSpace(name)]
+		if !ok {
+			return cfg, fmt.Errorf("unknown bot level %q", name)
+		}
+		cfg.levels = append(cfg.levels, level)
+	}
+	if len(cfg.levels) < models.MinPlayers || len(cfg.levels) > models.MaxPlayers {
+		return cfg, fmt.Errorf("need %d to %d bots, got %d", models.MinPlayers, models.MaxPlayers, len(cfg.levels))
+	}
+
+	rules, ok := models.RuleVariants[variant]
+	if !ok {
+		return cfg, fmt.Errorf("unknown rule variant %q", variant)
+	}
+	cfg.rules = rules
+	if _, ok := models.Compositions[deck]; !ok {
+		return cfg, fmt.Errorf("unknown deck %q", deck)
+	}
+	if games < 1 || numDeals < 1 {
+		return cfg, fmt.Errorf("games and deals must be positive")
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	return cfg, nil
+}
+
+// run plays every match of cfg, spread over cfg.workers goroutines. Each
+// match is seeded from its number, so results don't depend on scheduling.
+func run(cfg config) Report {
+	results := make([]matchResult, cfg.games)
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range next {
+				results[game] = playMatch(cfg, game)
+			}
+		}()
+	}
+	for game := 0; game < cfg.games; game++ {
+		next <- game
+	}
+	close(next)
+	wg.Wait()
+
+	return summarize(cfg, results)
+}
+
+// playMatch plays match number game. Seats rotate between matches so every
+// level gets every seat.
+func playMatch(cfg config, game int) matchResult {
+	numSeats := len(cfg.levels)
+	matchSeed := cfg.seed*1_000_003 + int64(game)
+
+	bots := make([]*bot.Bot, numSeats)
+	levels := make(map[string]bot.Level, numSeats)
+	playerIDs := make([]string, numSeats)
+	for seat := range bots {
+		level := cfg.levels[(seat+game)%numSeats]
+		playerIDs[seat] = fmt.Sprintf("seat%d", seat+1)
+		bots[seat] = bot.New(playerIDs[seat], level, matchSeed+int64(seat))
+		bots[seat].Budget = cfg.budget
+		levels[playerIDs[seat]] = level
+	}
+
+	state, err := bot.PlayMatch(bots, models.GameState{
+		Seats:    models.NewSeats(playerIDs),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: cfg.numDeals,
+		Rules:    cfg.rules,
+		Dealing:  cfg.dealing,
+	}, matchSeed)
+	return matchResult{levels: levels, state: state, err: err}
+}
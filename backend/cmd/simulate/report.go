@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// matchResult is one played match and which level sat where
+type matchResult struct {
+	levels map[string]bot.Level // By player ID
+	state  models.GameState
+	err    error
+}
+
+// Report is the outcome of a simulation run
+type Report struct {
+	Games      int             `json:"games"`
+	Seats      int             `json:"seats"`
+	NumDeals   int             `json:"numDeals"`
+	Rules      string          `json:"rules"`
+	Deck       string          `json:"deck"`
+	Seed       int64           `json:"seed"`
+	Errors     int             `json:"errors"` // Matches aborted by the rules engine
+	FirstError string          `json:"firstError,omitempty"`
+	Strategies []StrategyStats `json:"strategies"`
+}
+
+// StrategyStats aggregates every seat played by one bot level
+type StrategyStats struct {
+	Strategy     string  `json:"strategy"`
+	Seats        int     `json:"seats"` // Seats played over all matches
+	Wins         int     `json:"wins"`  // Matches finished first, ties included
+	WinRate      float64 `json:"winRate"`
+	AverageScore float64 `json:"averageScore"` // Final total per match
+	Bids         int     `json:"bids"`
+	BidAccuracy  float64 `json:"bidAccuracy"`  // Share of bids made
+	ExactBidRate float64 `json:"exactBidRate"` // Share of bids made without overtricks
+	AverageBid   float64 `json:"averageBid"`
+}
+
+type tally struct {
+	seats, wins, bids, made, exact int
+	score, bidSum                  float64
+}
+
+func summarize(cfg config, results []matchResult) Report {
+	report := Report{
+		Games:    len(results),
+		Seats:    len(cfg.levels),
+		NumDeals: cfg.numDeals,
+		Rules:    cfg.rules.Variant,
+		Deck:     cfg.dealing.Composition().Name,
+		Seed:     cfg.seed,
+	}
+
+	tallies := make(map[bot.Level]*tally)
+	for _, level := range cfg.levels {
+		tallies[level] = &tally{}
+	}
+
+	for _, result := range results {
+		if result.err != nil {
+			if report.Errors == 0 {
+				report.FirstError = result.err.Error()
+			}
+			report.Errors++
+			continue
+		}
+
+		for _, standing := range engine.Standings(&result.state) {
+			t := tallies[result.levels[standing.PlayerID]]
+			t.seats++
+			t.score += standing.Total
+			if standing.Rank == 1 {
+				t.wins++
+			}
+		}
+		for _, deal := range result.state.History {
+			for playerID, bid := range deal.Bids {
+				t := tallies[result.levels[playerID]]
+				t.bids++
+				t.bidSum += float64(bid)
+				if deal.Tricks[playerID] >= bid {
+					t.made++
+				}
+				if deal.Tricks[playerID] == bid {
+					t.exact++
+				}
+			}
+		}
+	}
+
+	for level, t := range tallies {
+		stats := StrategyStats{Strategy: string(level), Seats: t.seats, Wins: t.wins, Bids: t.bids}
+		if t.seats > 0 {
+			stats.WinRate = round(float64(t.wins) / float64(t.seats))
+			stats.AverageScore = round(t.score / float64(t.seats))
+		}
+		if t.bids > 0 {
+			stats.BidAccuracy = round(float64(t.made) / float64(t.bids))
+			stats.ExactBidRate = round(float64(t.exact) / float64(t.bids))
+			stats.AverageBid = round(t.bidSum / float64(t.bids))
+		}
+		report.Strategies = append(report.Strategies, stats)
+	}
+	sort.Slice(report.Strategies, func(i, j int) bool {
+		return report.Strategies[i].Strategy < report.Strategies[j].Strategy
+	})
+	return report
+}
+
+func round(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// reportWriters write a report in each output format, by name
+var reportWriters = map[string]func(io.Writer, Report) error{
+	"json": writeJSON,
+	"csv":  writeCSV,
+}
+
+func writeJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeCSV writes one row per strategy
+func writeCSV(w io.Writer, report Report) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"strategy", "seats", "wins", "win_rate", "average_score", "bids", "bid_accuracy", "exact_bid_rate", "average_bid"})
+	for _, s := range report.Strategies {
+		out.Write([]string{
+			s.Strategy,
+			strconv.Itoa(s.Seats),
+			strconv.Itoa(s.Wins),
+			formatFloat(s.WinRate),
+			formatFloat(s.AverageScore),
+			strconv.Itoa(s.Bids),
+			formatFloat(s.BidAccuracy),
+			formatFloat(s.ExactBidRate),
+			formatFloat(s.AverageBid),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
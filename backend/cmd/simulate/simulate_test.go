@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunIsRepeatableAndCountsEverySeat(t *testing.T) {
+	cfg, err := newConfig(6, "easy,medium,hard", 2, "strict", "standard", 0, 7, 2, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := run(cfg)
+	if report.Errors != 0 {
+		t.Fatalf("%d matches failed: %s", report.Errors, report.FirstError)
+	}
+	for _, stats := range report.Strategies {
+		if stats.Seats != 6 {
+			t.Errorf("%s played %d seats, want 6", stats.Strategy, stats.Seats)
+		}
+		if stats.Bids != 12 {
+			t.Errorf("%s made %d bids, want 12", stats.Strategy, stats.Bids)
+		}
+	}
+
+	cfg.workers = 1
+	if again := run(cfg); !reflect.DeepEqual(again, report) {
+		t.Errorf("same seed gave different reports:\n%+v\n%+v", report, again)
+	}
+}
+
+func TestNewConfigRejectsBadInput(t *testing.T) {
+	bad := []struct {
+		bots, rules, deck string
+	}{
+		{"easy", "strict", "standard"},
+		{"easy,genius", "strict", "standard"},
+		{"easy,easy", "anything-goes", "standard"},
+		{"easy,easy", "strict", "tarot"},
+	}
+	for _, c := range bad {
+		if _, err := newConfig(1, c.bots, 1, c.rules, c.deck, 0, 1, 1, 0, 1); err == nil {
+			t.Errorf("accepted bots %q, rules %q, deck %q", c.bots, c.rules, c.deck)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	cfg, err := newConfig(2, "easy,medium", 1, "casual", "piquet", 2, 1, 1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeCSV(&out, run(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "strategy,seats,wins,win_rate") {
+		t.Errorf("unexpected csv:\n%s", out.String())
+	}
+}
@@ -132,3 +132,10 @@ type contextKey string
 
 // Create a constant for the claims key
 const claimsKey contextKey = "claims"
+
+// ClaimsFromContext returns the claims ValidateJWTMiddleware stored for the
+// request
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,133 @@
+// Package bot implements computer players that take a seat in a room like a
+// human would, deciding bids and plays from what that seat can see.
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// Level is how well a bot plays
+type Level string
+
+const (
+	Easy   Level = "easy"   // Rough bids, random legal cards
+	Medium Level = "medium" // Hand-strength bids, wins cheaply and discards low
+	Hard   Level = "hard"   // Searches sampled deals for the best card
+)
+
+// Levels are the difficulty levels by name
+var Levels = map[string]Level{
+	string(Easy):   Easy,
+	string(Medium): Medium,
+	string(Hard):   Hard,
+}
+
+// Bot plays one seat. It is not safe for concurrent use; the room that seats
+// it owns it.
+type Bot struct {
+	ID     string
+	Level  Level
+	Budget Budget // Search budget of hard bots
+	rng    *rand.Rand
+	seen   *memory
+}
+
+// New returns a bot for seat playerID. Bots given the same seed make the same
+// decisions in the same situations, as long as a hard bot's budget has no
+// time limit.
+func New(playerID string, level Level, seed int64) *Bot {
+	return &Bot{
+		ID:     playerID,
+		Level:  level,
+		Budget: DefaultBudget,
+		rng:    rand.New(rand.NewSource(seed)),
+		seen:   newMemory(),
+	}
+}
+
+// Bid picks the bot's bid for the current deal
+func (b *Bot) Bid(state models.GameState) int {
+	view := Visible(state, b.ID)
+	me := engine.PlayerByID(&view, b.ID)
+	if me == nil {
+		return 1
+	}
+
+	strength := Strength(me.Hand)
+	var bid int
+	switch b.Level {
+	case Easy:
+		bid = int(strength+0.5) + b.rng.Intn(3) - 1
+	default:
+		bid = int(strength + 0.5)
+	}
+	return legalBid(&view, me, bid)
+}
+
+// Play picks the card the bot puts on the table. It is always legal under the
+// game's rules.
+func (b *Bot) Play(state models.GameState) (models.Card, error) {
+	view := Visible(state, b.ID)
+	me := engine.PlayerByID(&view, b.ID)
+	if me == nil {
+		return models.Card{}, engine.ErrUnknownPlayer
+	}
+	legal := engine.LegalCards(&view, me)
+	if len(legal) == 0 {
+		return models.Card{}, fmt.Errorf("bot %s has no legal card to play", b.ID)
+	}
+
+	switch b.Level {
+	case Easy:
+		return legal[b.rng.Intn(len(legal))], nil
+	case Hard:
+		return b.search(&view, me, legal), nil
+	default:
+		return playSensibly(&view, legal), nil
+	}
+}
+
+// Observe lets the bot follow the game through the engine's events, so it can
+// remember which cards are gone.
+func (b *Bot) Observe(events []engine.Event) {
+	for _, event := range events {
+		b.seen.observe(event)
+	}
+}
+
+// Visible returns state as playerID sees it: every other hand is hidden.
+func Visible(state models.GameState, playerID string) models.GameState {
+	view := state.Clone()
+	for i := range view.Seats {
+		if view.Seats[i].ID != playerID {
+			view.Seats[i].Hand = nil
+		}
+	}
+	if view.RoundWinner != nil && view.RoundWinner.ID != playerID {
+		view.RoundWinner.Hand = nil
+	}
+	view.Seed = nil
+	return view
+}
+
+// legalBid moves bid into the range the rules allow and, when the dealer may
+// not make the total, off the forbidden number, preferring to bid lower
+func legalBid(state *models.GameState, me *models.Player, bid int) int {
+	low, high := engine.BidRange(state, me)
+	if bid > high {
+		bid = high
+	}
+	if bid < low {
+		bid = low
+	}
+	for _, candidate := range []int{bid, bid - 1, bid + 1} {
+		if engine.ValidateBid(state, me, candidate) == nil {
+			return candidate
+		}
+	}
+	return engine.DefaultBid(state, me)
+}
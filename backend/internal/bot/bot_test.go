@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// newTestBot returns a bot whose search is small and only counts samples
+func newTestBot(playerID string, level Level, seed int64) *Bot {
+	b := New(playerID, level, seed)
+	b.Budget = Budget{Samples: 3}
+	return b
+}
+
+// playGame plays a whole match between bots and returns the final state
+func playGame(t *testing.T, bots []*Bot, rules models.RuleSet, seed int64) models.GameState {
+	t.Helper()
+	ids := make([]string, len(bots))
+	for i, b := range bots {
+		ids[i] = b.ID
+	}
+	state, err := PlayMatch(bots, models.GameState{
+		Seats:    models.NewSeats(ids),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: 3,
+		Rules:    rules,
+	}, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestBotsOnlyPlayLegalCards(t *testing.T) {
+	for _, level := range []Level{Easy, Medium, Hard} {
+		for _, rules := range []models.RuleSet{models.StrictCallBreak, models.CasualCallBreak} {
+			for numPlayers := models.MinPlayers; numPlayers <= models.MaxPlayers; numPlayers++ {
+				bots := make([]*Bot, numPlayers)
+				for i := range bots {
+					bots[i] = newTestBot(fmt.Sprintf("bot%d", i), level, int64(i))
+				}
+				state := playGame(t, bots, rules, int64(numPlayers))
+				if len(state.History) != 3 {
+					t.Errorf("%s bots, %s rules, %d players: played %d deals", level, rules.Variant, numPlayers, len(state.History))
+				}
+			}
+		}
+	}
+}
+
+func TestBotsAreDeterministicUnderASeed(t *testing.T) {
+	play := func() models.GameState {
+		bots := []*Bot{newTestBot("a", Easy, 1), newTestBot("b", Medium, 2), newTestBot("c", Hard, 3), newTestBot("d", Hard, 4)}
+		return playGame(t, bots, models.StrictCallBreak, 99)
+	}
+	first, second := play(), play()
+	if fmt.Sprint(first.Totals) != fmt.Sprint(second.Totals) {
+		t.Errorf("same seeds gave different games: %v and %v", first.Totals, second.Totals)
+	}
+}
+
+func TestStrengthRewardsHighCards(t *testing.T) {
+	strong := []models.Card{
+		{Rank: models.Ace, Suit: models.Spades}, {Rank: models.King, Suit: models.Spades},
+		{Rank: models.Queen, Suit: models.Spades}, {Rank: models.Ace, Suit: models.Hearts},
+		{Rank: models.Ace, Suit: models.Clubs},
+	}
+	weak := []models.Card{
+		{Rank: models.Two, Suit: models.Hearts}, {Rank: models.Three, Suit: models.Hearts},
+		{Rank: models.Four, Suit: models.Clubs}, {Rank: models.Five, Suit: models.Diamonds},
+		{Rank: models.Six, Suit: models.Clubs},
+	}
+	if s := Strength(strong); s < 4.5 {
+		t.Errorf("strong hand rated %.1f tricks", s)
+	}
+	if s := Strength(weak); s > 0.5 {
+		t.Errorf("weak hand rated %.1f tricks", s)
+	}
+}
+
+func TestVisibleHidesOtherHands(t *testing.T) {
+	state := models.GameState{Seats: models.NewSeats([]string{"a", "b"}), Seed: models.SeedOf(7)}
+	state.Seats[0].Hand = []models.Card{{Rank: models.Ace, Suit: models.Spades}}
+	state.Seats[1].Hand = []models.Card{{Rank: models.King, Suit: models.Spades}}
+
+	view := Visible(state, "a")
+	if len(view.Seats[0].Hand) != 1 || len(view.Seats[1].Hand) != 0 || view.Seed != nil {
+		t.Errorf("bot a sees %v, %v and seed %v", view.Seats[0].Hand, view.Seats[1].Hand, view.Seed)
+	}
+	if len(state.Seats[1].Hand) != 1 {
+		t.Error("Visible changed the original state")
+	}
+}
+
+// midDeal deals four hands, b getting every club, and has a lead a heart
+// which b can't follow. It returns the state with c to play and the events
+// so far.
+func midDeal(t *testing.T) (models.GameState, []engine.Event) {
+	t.Helper()
+	state := models.GameState{
+		Seats:    models.NewSeats([]string{"a", "b", "c", "d"}),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: 1,
+		Rules:    models.CasualCallBreak,
+	}
+
+	hands := make([][]models.Card, 4)
+	for _, card := range models.StandardDeck.Cards() {
+		switch {
+		case card.Suit == models.Clubs:
+			hands[1] = append(hands[1], card)
+		case len(hands[0]) < 13:
+			hands[0] = append(hands[0], card)
+		case len(hands[2]) < 13:
+			hands[2] = append(hands[2], card)
+		default:
+			hands[3] = append(hands[3], card)
+		}
+	}
+
+	var events []engine.Event
+	apply := func(action engine.Action) {
+		next, produced, err := engine.Apply(state, action)
+		if err != nil {
+			t.Fatalf("%T: %v", action, err)
+		}
+		state = next
+		events = append(events, produced...)
+	}
+	apply(engine.Deal{Hands: hands})
+	for _, id := range []string{"a", "b", "c", "d"} {
+		apply(engine.Bid{PlayerID: id, Amount: 2})
+	}
+	apply(engine.PlayCard{PlayerID: "a", Card: hands[0][0]})
+	apply(engine.PlayCard{PlayerID: "b", Card: hands[1][0]})
+	return state, events
+}
+
+func TestSampledHandsRespectVoids(t *testing.T) {
+	state, events := midDeal(t)
+	b := newTestBot("c", Hard, 1)
+	b.Observe(events)
+
+	view := Visible(state, "c")
+	me := engine.PlayerByID(&view, "c")
+	for i := 0; i < 50; i++ {
+		world, ok := b.sampleWorld(&view, me)
+		if !ok {
+			t.Fatal("no consistent deal found")
+		}
+		for _, player := range world.Players() {
+			want := 13
+			if player.PlayedCard != nil {
+				want = 12
+			}
+			if len(player.Hand) != want {
+				t.Fatalf("%s was dealt %d cards, want %d", player.ID, len(player.Hand), want)
+			}
+		}
+		for _, card := range engine.PlayerByID(&world, "b").Hand {
+			if card.Suit == models.Hearts {
+				t.Fatalf("b showed a void in hearts but was dealt %v", card)
+			}
+		}
+	}
+}
+
+func TestSearchIsRepeatableUnderASeed(t *testing.T) {
+	state, events := midDeal(t)
+	decide := func() models.Card {
+		b := New("c", Hard, 42)
+		b.Budget = Budget{Samples: 20}
+		b.Observe(events)
+		card, err := b.Play(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return card
+	}
+
+	first := decide()
+	for i := 0; i < 3; i++ {
+		if card := decide(); card != first {
+			t.Fatalf("same seed chose %v, then %v", first, card)
+		}
+	}
+}
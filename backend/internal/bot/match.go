@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// PlayMatch plays state to the end with a bot in every seat, in seat order.
+// Deals are shuffled from seeds drawn from seed, so the same bots, state and
+// seed always play the same match. It stops at the first action the engine
+// rejects.
+func PlayMatch(bots []*Bot, state models.GameState, seed int64) (models.GameState, error) {
+	byID := make(map[string]*Bot, len(bots))
+	for _, b := range bots {
+		byID[b.ID] = b
+	}
+	for _, seat := range state.Seats {
+		if byID[seat.ID] == nil {
+			return state, fmt.Errorf("no bot for seat %s", seat.ID)
+		}
+	}
+
+	seeds := rand.New(rand.NewSource(seed))
+	for state.Phase != models.PhaseOver {
+		var action engine.Action
+		switch state.Phase {
+		case models.PhaseDealing:
+			dealSeed := models.SeedOf(seeds.Int63())
+			action = engine.Deal{Seed: dealSeed, Hands: state.Dealing.Hands(len(state.Seats), dealSeed)}
+		case models.PhaseBidding, models.PhasePlaying:
+			current := engine.CurrentPlayer(&state)
+			if current == nil {
+				return state, fmt.Errorf("turn %d points at no seat", state.Turn)
+			}
+			if state.Phase == models.PhaseBidding {
+				action = engine.Bid{PlayerID: current.ID, Amount: byID[current.ID].Bid(state)}
+				break
+			}
+			card, err := byID[current.ID].Play(state)
+			if err != nil {
+				return state, err
+			}
+			action = engine.PlayCard{PlayerID: current.ID, Card: card}
+		default:
+			return state, fmt.Errorf("unexpected phase %q", state.Phase)
+		}
+
+		next, events, err := engine.Apply(state, action)
+		if err != nil {
+			return state, fmt.Errorf("deal %d: %T rejected: %w", state.Deal, action, err)
+		}
+		for _, b := range bots {
+			b.Observe(events)
+		}
+		state = next
+	}
+	return state, nil
+}
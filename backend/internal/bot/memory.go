@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"math/rand/v2"
+
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// memory is what a bot remembers of the current deal
+type memory struct {
+	played []models.Card
+	trick  []models.PlayedCardMessage // Cards on the table, in the order played
+
+	// What players showed they don't hold by not following suit
+	voids map[string]map[models.Suit]bool
+	// Players who discarded instead of trumping a trick no spade was winning.
+	// Under must-trump rules they hold no spades.
+	declinedTrump map[string]bool
+}
+
+func newMemory() *memory {
+	m := &memory{}
+	m.reset()
+	return m
+}
+
+func (m *memory) reset() {
+	m.played = nil
+	m.trick = nil
+	m.voids = make(map[string]map[models.Suit]bool)
+	m.declinedTrump = make(map[string]bool)
+}
+
+func (m *memory) observe(event engine.Event) {
+	switch e := event.(type) {
+	case engine.DealStarted:
+		m.reset()
+	case engine.CardPlayed:
+		m.played = append(m.played, e.Card)
+		m.inferVoids(e.PlayerID, e.Card)
+		m.trick = append(m.trick, models.PlayedCardMessage{PlayerID: e.PlayerID, Card: e.Card})
+	case engine.TrickWon:
+		m.trick = nil
+	}
+}
+
+// inferVoids notes what playing card says about playerID's hand
+func (m *memory) inferVoids(playerID string, card models.Card) {
+	if len(m.trick) == 0 {
+		return
+	}
+	led := m.trick[0].Card.Suit
+	if card.Suit == led {
+		return
+	}
+
+	if m.voids[playerID] == nil {
+		m.voids[playerID] = make(map[models.Suit]bool)
+	}
+	m.voids[playerID][led] = true
+
+	best := m.trick[0].Card
+	for _, played := range m.trick[1:] {
+		if engine.Beats(played.Card, best, led) {
+			best = played.Card
+		}
+	}
+	if card.Suit != engine.Trump && best.Suit != engine.Trump {
+		m.declinedTrump[playerID] = true
+	}
+}
+
+// void reports whether playerID is known to hold no card of suit
+func (m *memory) void(state *models.GameState, playerID string, suit models.Suit) bool {
+	if m.voids[playerID][suit] {
+		return true
+	}
+	return suit == engine.Trump && m.declinedTrump[playerID] && engine.Rules(state).MustTrump
+}
+
+// unseen counts the dealt cards that are neither in hand nor played
+func (m *memory) unseen(state *models.GameState, hand []models.Card) map[models.Card]int {
+	deck := models.NewDeck(state.Dealing.Composition(), rand.NewPCG(0, 0))
+	deck.Trim(len(state.Seats))
+
+	unseen := make(map[models.Card]int)
+	for _, card := range deck.Cards {
+		unseen[card]++
+	}
+	for _, card := range hand {
+		unseen[card]--
+	}
+	for _, card := range m.played {
+		unseen[card]--
+	}
+	return unseen
+}
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"sort"
+	"time"
+
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// Budget bounds how long the hard bot searches for a card. It stops after
+// Samples deals have been tried or Time has passed, whichever comes first. A
+// zero Time only counts samples, which keeps decisions repeatable under a
+// seed.
+type Budget struct {
+	Samples int
+	Time    time.Duration
+}
+
+// DefaultBudget is the search budget of new hard bots
+var DefaultBudget = Budget{Samples: 64, Time: 250 * time.Millisecond}
+
+// search picks a card by determinization: it deals the unseen cards to the
+// other seats in ways consistent with what they have shown, plays the rest
+// of the deal out with the engine for every legal card, and keeps the card
+// that scored best on average.
+func (b *Bot) search(state *models.GameState, me *models.Player, legal []models.Card) models.Card {
+	if len(legal) == 1 {
+		return legal[0]
+	}
+
+	started := time.Now()
+	totals := make([]float64, len(legal))
+	samples := 0
+	for samples < b.Budget.Samples {
+		if b.Budget.Time > 0 && time.Since(started) >= b.Budget.Time {
+			break
+		}
+
+		world, ok := b.sampleWorld(state, me)
+		if !ok {
+			break
+		}
+		for i, card := range legal {
+			totals[i] += rollout(world, me.ID, card)
+		}
+		samples++
+	}
+	if samples == 0 {
+		return playCounting(state, me, legal, b.seen)
+	}
+
+	best := 0
+	for i := range legal {
+		if totals[i] > totals[best] || (totals[i] == totals[best] && cost(legal[i]) < cost(legal[best])) {
+			best = i
+		}
+	}
+	return legal[best]
+}
+
+// sampleWorld deals the cards the bot hasn't seen to the other seats. Every
+// seat gets as many cards as it holds and none of a suit it is known to be
+// void in. It reports false when no such deal could be found.
+func (b *Bot) sampleWorld(state *models.GameState, me *models.Player) (models.GameState, bool) {
+	// Everyone started the trick with as many cards as we did
+	handSize := len(me.Hand)
+	if me.PlayedCard != nil {
+		handSize++
+	}
+
+	var others []*models.Player
+	need := make(map[string]int)
+	for _, player := range state.Players() {
+		if player.ID == me.ID {
+			continue
+		}
+		others = append(others, player)
+		need[player.ID] = handSize
+		if player.PlayedCard != nil {
+			need[player.ID]--
+		}
+	}
+
+	var pool []models.Card
+	for card, count := range b.seen.unseen(state, me.Hand) {
+		for ; count > 0; count-- {
+			pool = append(pool, card)
+		}
+	}
+	sortCards(pool)
+
+	const attempts = 20
+	for attempt := 0; attempt < attempts; attempt++ {
+		if hands, ok := b.dealPool(state, pool, others, need); ok {
+			// Rollouts only look at the deal being played, leaving the
+			// match history out saves copying it on every card
+			world := state.Clone()
+			world.History = nil
+			world.Totals = nil
+			for _, player := range world.Players() {
+				if hand, dealt := hands[player.ID]; dealt {
+					player.Hand = hand
+				}
+			}
+			return world, true
+		}
+	}
+	return models.GameState{}, false
+}
+
+// dealPool makes one random attempt at dealing pool to others, placing the
+// cards with the fewest possible owners first
+func (b *Bot) dealPool(state *models.GameState, pool []models.Card, others []*models.Player, need map[string]int) (map[string][]models.Card, bool) {
+	owners := make(map[models.Suit][]string)
+	for _, suit := range []models.Suit{models.Hearts, models.Diamonds, models.Clubs, models.Spades} {
+		for _, player := range others {
+			if !b.seen.void(state, player.ID, suit) {
+				owners[suit] = append(owners[suit], player.ID)
+			}
+		}
+	}
+
+	cards := append([]models.Card(nil), pool...)
+	b.rng.Shuffle(len(cards), func(i, j int) { cards[i], cards[j] = cards[j], cards[i] })
+	sort.SliceStable(cards, func(i, j int) bool {
+		return len(owners[cards[i].Suit]) < len(owners[cards[j].Suit])
+	})
+
+	left := make(map[string]int, len(need))
+	total := 0
+	for id, n := range need {
+		left[id] = n
+		total += n
+	}
+
+	hands := make(map[string][]models.Card, len(others))
+	for _, card := range cards {
+		if total == 0 {
+			break
+		}
+		var open []string
+		for _, id := range owners[card.Suit] {
+			if left[id] > 0 {
+				open = append(open, id)
+			}
+		}
+		if len(open) == 0 {
+			continue
+		}
+		id := open[b.rng.Intn(len(open))]
+		hands[id] = append(hands[id], card)
+		left[id]--
+		total--
+	}
+	return hands, total == 0
+}
+
+// rollout plays card for playerID in world, lets every seat play the rest of
+// the deal sensibly and returns how far playerID finished ahead of the
+// average of the others
+func rollout(world models.GameState, playerID string, card models.Card) float64 {
+	deal := world.Deal
+	state := world.Clone()
+	if _, err := engine.Step(&state, engine.PlayCard{PlayerID: playerID, Card: card}); err != nil {
+		return -1000
+	}
+
+	for state.Phase == models.PhasePlaying && state.Deal == deal {
+		current := engine.CurrentPlayer(&state)
+		legal := engine.LegalCards(&state, current)
+		if len(legal) == 0 {
+			break
+		}
+		if _, err := engine.Step(&state, engine.PlayCard{PlayerID: current.ID, Card: playSensibly(&state, legal)}); err != nil {
+			break
+		}
+	}
+
+	if len(state.History) == 0 || state.History[len(state.History)-1].Deal != deal {
+		return 0
+	}
+	// Add up in seat order, map order would make the sums differ in the
+	// last bits from one run to the next
+	points := state.History[len(state.History)-1].Points
+	others := 0.0
+	for _, seat := range state.Seats {
+		if seat.ID != playerID {
+			others += points[seat.ID]
+		}
+	}
+	if len(state.Seats) > 1 {
+		others /= float64(len(state.Seats) - 1)
+	}
+	return points[playerID] - others
+}
+
+// sortCards puts cards in a fixed order so sampling only depends on the seed
+func sortCards(cards []models.Card) {
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].Suit != cards[j].Suit {
+			return cards[i].Suit < cards[j].Suit
+		}
+		return engine.RankValue(cards[i].Rank) < engine.RankValue(cards[j].Rank)
+	})
+}
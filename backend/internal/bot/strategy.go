@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+)
+
+// ************************** BIDDING ********************************************
+
+// Strength estimates how many tricks hand takes with spades as trumps
+func Strength(hand []models.Card) float64 {
+	bySuit := make(map[models.Suit][]models.Card)
+	for _, card := range hand {
+		bySuit[card.Suit] = append(bySuit[card.Suit], card)
+	}
+
+	tricks := 0.0
+	spades := len(bySuit[engine.Trump])
+	spareSpades := spades
+	for _, card := range bySuit[engine.Trump] {
+		switch card.Rank {
+		case models.Ace:
+			tricks++
+			spareSpades--
+		case models.King:
+			if spades >= 2 {
+				tricks++
+				spareSpades--
+			} else {
+				tricks += 0.5
+			}
+		case models.Queen:
+			if spades >= 3 {
+				tricks += 0.8
+				spareSpades--
+			} else {
+				tricks += 0.3
+			}
+		}
+	}
+	// Long trumps win once the others have run out
+	if spades > 3 {
+		tricks += 0.8 * float64(spades-3)
+		spareSpades -= spades - 3
+	}
+
+	for _, suit := range []models.Suit{models.Hearts, models.Diamonds, models.Clubs} {
+		cards := bySuit[suit]
+		length := len(cards)
+		for _, card := range cards {
+			switch {
+			case card.Rank == models.Ace && length <= 5:
+				tricks++
+			case card.Rank == models.Ace:
+				tricks += 0.6
+			case card.Rank == models.King && length >= 2 && length <= 4:
+				tricks += 0.7
+			case card.Rank == models.Queen && length >= 3 && length <= 4:
+				tricks += 0.3
+			}
+		}
+
+		// Short side suits let spare trumps ruff
+		if spareSpades <= 0 {
+			continue
+		}
+		switch length {
+		case 0:
+			tricks += 0.8
+			spareSpades--
+		case 1:
+			tricks += 0.4
+			spareSpades--
+		}
+	}
+	return tricks
+}
+
+// ************************** PLAYING ********************************************
+
+// playSensibly wins a trick with the cheapest card that does it and throws
+// the lowest card otherwise. It leads aces, or low from its longest side suit.
+func playSensibly(state *models.GameState, legal []models.Card) models.Card {
+	if state.TrickSuit == "" {
+		for _, card := range legal {
+			if card.Rank == models.Ace && card.Suit != engine.Trump {
+				return card
+			}
+		}
+		return lowestOfLongest(legal)
+	}
+
+	if winner := cheapestWinner(state, legal); winner != nil {
+		return *winner
+	}
+	return lowest(legal)
+}
+
+// playCounting plays like playSensibly but uses what has been played so far
+// to lead cards nobody can beat and to avoid winners that will be overtaken.
+func playCounting(state *models.GameState, me *models.Player, legal []models.Card, seen *memory) models.Card {
+	unseen := seen.unseen(state, me.Hand)
+
+	if state.TrickSuit == "" {
+		for _, card := range legal {
+			if card.Suit != engine.Trump && isMaster(card, unseen) {
+				return card
+			}
+		}
+		return lowestOfLongest(legal)
+	}
+
+	winner := cheapestWinner(state, legal)
+	if winner == nil {
+		return lowest(legal)
+	}
+	if lastToPlay(state) || winner.Suit == engine.Trump || isMaster(*winner, unseen) {
+		return *winner
+	}
+
+	// Someone after us can still overtake, use the best card we have in the
+	// suit if that is safe, otherwise don't waste a high card
+	for _, card := range legal {
+		if card.Suit == winner.Suit && isMaster(card, unseen) {
+			return card
+		}
+	}
+	return lowest(legal)
+}
+
+// cheapestWinner returns the lowest legal card that would take the trick as
+// it stands, or nil
+func cheapestWinner(state *models.GameState, legal []models.Card) *models.Card {
+	best := engine.TrickWinner(state)
+	var cheapest *models.Card
+	for i, card := range legal {
+		if best != nil && !engine.Beats(card, *best.PlayedCard, state.TrickSuit) {
+			continue
+		}
+		if cheapest == nil || cost(card) < cost(*cheapest) {
+			cheapest = &legal[i]
+		}
+	}
+	return cheapest
+}
+
+// lastToPlay reports whether every other seat already has a card on the table
+func lastToPlay(state *models.GameState) bool {
+	waiting := 0
+	for _, player := range state.Players() {
+		if player.PlayedCard == nil {
+			waiting++
+		}
+	}
+	return waiting <= 1
+}
+
+// isMaster reports whether no unseen card of the same suit beats card
+func isMaster(card models.Card, unseen map[models.Card]int) bool {
+	for other, count := range unseen {
+		if count > 0 && other.Suit == card.Suit && engine.RankValue(other.Rank) > engine.RankValue(card.Rank) {
+			return false
+		}
+	}
+	return true
+}
+
+// cost orders cards by how much it hurts to give them up, trumps last
+func cost(card models.Card) int {
+	value := engine.RankValue(card.Rank)
+	if card.Suit == engine.Trump {
+		value += 13
+	}
+	return value
+}
+
+func lowest(cards []models.Card) models.Card {
+	low := cards[0]
+	for _, card := range cards[1:] {
+		if cost(card) < cost(low) {
+			low = card
+		}
+	}
+	return low
+}
+
+// lowestOfLongest returns the lowest card of the longest non-trump suit in
+// cards, or the lowest card when only trumps are left
+func lowestOfLongest(cards []models.Card) models.Card {
+	counts := make(map[models.Suit]int)
+	for _, card := range cards {
+		if card.Suit != engine.Trump {
+			counts[card.Suit]++
+		}
+	}
+
+	var longest models.Suit
+	for _, suit := range []models.Suit{models.Hearts, models.Diamonds, models.Clubs} {
+		if counts[suit] > counts[longest] {
+			longest = suit
+		}
+	}
+	if longest == "" {
+		return lowest(cards)
+	}
+
+	var inSuit []models.Card
+	for _, card := range cards {
+		if card.Suit == longest {
+			inSuit = append(inSuit, card)
+		}
+	}
+	return lowest(inSuit)
+}
@@ -1,5 +1,11 @@
 package config
 import (
-	"dealer-backend/internal/models"
+	"dealer-backend/internal/services"
 )
-var PlayerConnections = models.NewPlayerConnections()
\ No newline at end of file
+var PlayerConnections = services.NewPlayerConnections()
+
+// Matchmaker seats the players queueing in the lobby
+var Matchmaker = services.NewMatchmaker(PlayerConnections)
+
+// PrivateRooms are the rooms players of the lobby gather in by join code
+var PrivateRooms = services.NewPrivateRooms(PlayerConnections)
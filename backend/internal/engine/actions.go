@@ -0,0 +1,51 @@
+package engine
+
+import "dealer-backend/internal/models"
+
+// Action is something a player (or the clock, or the dealer) does to the
+// game. Apply accepts one of Deal, Redeal, Bid, PlayCard or Timeout.
+type Action interface {
+	actor() string
+}
+
+// Deal starts the next deal of the match with the given hands, one per seat
+// in seat order. Seed is the shuffle seed the hands were dealt from; it is
+// not used by Apply but lets the deal be recorded and regenerated.
+type Deal struct {
+	Seed  models.Seed
+	Hands [][]models.Card
+}
+
+// Redeal asks for the hands to be thrown in and dealt again. It is allowed
+// before the first bid, to a player whose hand meets one of the misdeal
+// conditions of the rules.
+type Redeal struct {
+	PlayerID string
+}
+
+// Bid records the number of tricks a player aims to win.
+type Bid struct {
+	PlayerID string
+	Amount   int
+}
+
+// PlayCard puts a card from the player's hand on the table.
+type PlayCard struct {
+	PlayerID string
+	Card     models.Card
+}
+
+// Timeout is raised when a player let their timer run out. During bidding
+// they get the lowest bid the rules allow. During play a card is put on the
+// table for them: Card if it is given and legal, their lowest legal card
+// otherwise.
+type Timeout struct {
+	PlayerID string
+	Card     *models.Card
+}
+
+func (a Deal) actor() string     { return "" }
+func (a Redeal) actor() string   { return a.PlayerID }
+func (a Bid) actor() string      { return a.PlayerID }
+func (a PlayCard) actor() string { return a.PlayerID }
+func (a Timeout) actor() string  { return a.PlayerID }
@@ -0,0 +1,338 @@
+// Package engine implements the Call Break rules as a pure function of the
+// game state. It knows nothing about sockets, timers or rooms: callers feed
+// it actions and broadcast the events it returns.
+package engine
+
+import (
+	"fmt"
+
+	"dealer-backend/internal/models"
+)
+
+// Apply validates action against state and returns the resulting state
+// together with the events it produced. state itself is never modified; on
+// error the returned state is the one passed in.
+func Apply(state models.GameState, action Action) (models.GameState, []Event, error) {
+	next := state.Clone()
+	events, err := Step(&next, action)
+	if err != nil {
+		return state, nil, err
+	}
+	return next, events, nil
+}
+
+// Step is Apply without the copy: it changes state in place. It is meant for
+// simulations that own their state and play many actions on it; actions are
+// validated before anything is changed, so a rejected action leaves state
+// as it was.
+func Step(state *models.GameState, action Action) ([]Event, error) {
+	switch a := action.(type) {
+	case Deal:
+		return applyDeal(state, a)
+	case Redeal:
+		return applyRedeal(state, a)
+	case Bid:
+		return applyBid(state, a)
+	case PlayCard:
+		return applyPlayCard(state, a)
+	case Timeout:
+		return applyTimeout(state, a)
+	default:
+		return nil, fmt.Errorf("engine: unknown action %T", action)
+	}
+}
+
+// ************************** DEALING ********************************************
+
+func applyDeal(state *models.GameState, deal Deal) ([]Event, error) {
+	if state.Phase != models.PhaseDealing {
+		return nil, ErrWrongPhase
+	}
+	players := state.Players()
+	if len(deal.Hands) != len(players) {
+		return nil, ErrBadDeal
+	}
+
+	for i, player := range players {
+		player.Hand = append([]models.Card(nil), deal.Hands[i]...)
+		player.PlayedCard = nil
+		player.Bid = 0
+		player.Score = 0
+	}
+	// The deal passes to the left after every deal, thrown in hands are
+	// dealt again by the same dealer
+	state.Dealer = state.DealerPosition()
+	if !state.Redeal {
+		if state.Deal > 0 {
+			state.Dealer = state.Dealer%len(players) + 1
+		}
+		state.Deal++
+	}
+	state.Redeal = false
+	state.Seed = deal.Seed
+	state.Turn = firstToBid(state)
+	state.TrickSuit = ""
+	state.RoundWinner = nil
+	state.LastPlay = nil
+	state.Scores = make(map[string]int)
+	state.Bids = make(map[string]int)
+	state.Phase = models.PhaseBidding
+
+	dealer := players[state.Dealer-1].ID
+	events := []Event{DealStarted{Deal: state.Deal, Dealer: dealer}}
+	return append(events, autoRedeal(state)...), nil
+}
+
+// ************************** BIDDING ********************************************
+
+func applyBid(state *models.GameState, bid Bid) ([]Event, error) {
+	if state.Phase != models.PhaseBidding {
+		return nil, ErrWrongPhase
+	}
+	player := PlayerByID(state, bid.PlayerID)
+	if player == nil {
+		return nil, ErrUnknownPlayer
+	}
+	if _, done := state.Bids[player.ID]; done {
+		return nil, ErrAlreadyBid
+	}
+	if CurrentPlayer(state) != player {
+		return nil, ErrNotYourTurn
+	}
+	if err := ValidateBid(state, player, bid.Amount); err != nil {
+		return nil, err
+	}
+
+	events := []Event{recordBid(state, player, bid.Amount)}
+	return append(events, checkBiddingComplete(state)...), nil
+}
+
+func recordBid(state *models.GameState, player *models.Player, amount int) Event {
+	if state.Bids == nil {
+		state.Bids = make(map[string]int)
+	}
+	state.Bids[player.ID] = amount
+	player.Bid = amount
+	return BidPlaced{PlayerID: player.ID, Amount: amount}
+}
+
+// checkBiddingComplete passes the bid on to the next seat. Bidding goes round
+// the table once, so after the dealer the turn is back with the seat on their
+// left, who leads the first trick.
+func checkBiddingComplete(state *models.GameState) []Event {
+	advanceTurn(state)
+	for _, player := range state.Players() {
+		if _, done := state.Bids[player.ID]; !done {
+			return nil
+		}
+	}
+	state.Turn = firstToBid(state)
+	state.Phase = models.PhasePlaying
+	return []Event{BiddingComplete{}}
+}
+
+// firstToBid is the position of the seat left of the dealer
+func firstToBid(state *models.GameState) int {
+	return state.DealerPosition()%len(state.Seats) + 1
+}
+
+// ************************** PLAYING ********************************************
+
+func applyPlayCard(state *models.GameState, play PlayCard) ([]Event, error) {
+	if state.Phase != models.PhasePlaying {
+		return nil, ErrWrongPhase
+	}
+	player := PlayerByID(state, play.PlayerID)
+	if player == nil {
+		return nil, ErrUnknownPlayer
+	}
+	if CurrentPlayer(state) != player {
+		return nil, ErrNotYourTurn
+	}
+	if player.PlayedCard != nil {
+		return nil, ErrAlreadyPlayed
+	}
+	index := cardIndex(player.Hand, play.Card)
+	if index < 0 {
+		return nil, ErrCardNotInHand
+	}
+	if err := ValidateCard(state, player, play.Card); err != nil {
+		return nil, err
+	}
+
+	// The first card of the trick sets the suit everyone must follow
+	if state.TrickSuit == "" {
+		state.TrickSuit = play.Card.Suit
+	}
+
+	card := player.Hand[index]
+	player.Hand = append(player.Hand[:index:index], player.Hand[index+1:]...)
+	player.PlayedCard = &card
+	state.LastPlay = &models.PlayedCardMessage{PlayerID: player.ID, Card: card}
+
+	events := []Event{CardPlayed{PlayerID: player.ID, Card: card}}
+	if !trickComplete(state) {
+		advanceTurn(state)
+		return events, nil
+	}
+	return append(events, finishTrick(state)...), nil
+}
+
+func applyTimeout(state *models.GameState, timeout Timeout) ([]Event, error) {
+	player := PlayerByID(state, timeout.PlayerID)
+	if player == nil {
+		return nil, ErrUnknownPlayer
+	}
+
+	switch state.Phase {
+	case models.PhaseBidding:
+		// A player who doesn't bid in time gets the lowest bid allowed
+		if _, done := state.Bids[player.ID]; done {
+			return nil, ErrAlreadyBid
+		}
+		if CurrentPlayer(state) != player {
+			return nil, ErrNotYourTurn
+		}
+		amount := DefaultBid(state, player)
+		events := []Event{AutoBid{PlayerID: player.ID, Amount: amount}, recordBid(state, player, amount)}
+		return append(events, checkBiddingComplete(state)...), nil
+	case models.PhasePlaying:
+		if CurrentPlayer(state) != player {
+			return nil, ErrNotYourTurn
+		}
+		card, ok := autoPlayCard(state, player, timeout.Card)
+		if !ok {
+			return nil, ErrCardNotInHand
+		}
+		events, err := applyPlayCard(state, PlayCard{PlayerID: player.ID, Card: card})
+		if err != nil {
+			return nil, err
+		}
+		return append([]Event{AutoPlayed{PlayerID: player.ID, Card: card}}, events...), nil
+	default:
+		return nil, ErrWrongPhase
+	}
+}
+
+// autoPlayCard picks the card played for a timed out player: choice when it
+// is legal, otherwise the lowest legal card, keeping spades for last
+func autoPlayCard(state *models.GameState, player *models.Player, choice *models.Card) (models.Card, bool) {
+	if choice != nil && cardIndex(player.Hand, *choice) >= 0 && ValidateCard(state, player, *choice) == nil {
+		return *choice, true
+	}
+
+	legal := LegalCards(state, player)
+	if len(legal) == 0 {
+		return models.Card{}, false
+	}
+	lowest := legal[0]
+	for _, card := range legal[1:] {
+		if (lowest.Suit == Trump && card.Suit != Trump) ||
+			(lowest.Suit == Trump) == (card.Suit == Trump) && RankValue(card.Rank) < RankValue(lowest.Rank) {
+			lowest = card
+		}
+	}
+	return lowest, true
+}
+
+// finishTrick awards the trick, clears the table and hands the lead to the
+// winner. The deal is scored once every hand is empty.
+func finishTrick(state *models.GameState) []Event {
+	winner := TrickWinner(state)
+
+	cards := make([]models.PlayedCardMessage, 0, len(state.Players()))
+	for _, player := range state.Players() {
+		if player.PlayedCard != nil {
+			cards = append(cards, models.PlayedCardMessage{PlayerID: player.ID, Card: *player.PlayedCard})
+		}
+	}
+
+	winner.Score++
+	if state.Scores == nil {
+		state.Scores = make(map[string]int)
+	}
+	state.Scores[winner.ID]++
+
+	for _, player := range state.Players() {
+		player.PlayedCard = nil
+	}
+	state.TrickSuit = ""
+	state.Turn = state.GetPlayerPosition(*winner)
+
+	roundWinner := *winner
+	roundWinner.Hand = append([]models.Card(nil), winner.Hand...)
+	state.RoundWinner = &roundWinner
+
+	events := []Event{TrickWon{PlayerID: winner.ID, Cards: cards}}
+	if dealFinished(state) {
+		events = append(events, finishDeal(state)...)
+	}
+	return events
+}
+
+// finishDeal scores the deal and either waits for the next one or, after the
+// last deal of the match, ends the game.
+func finishDeal(state *models.GameState) []Event {
+	result := scoreDeal(state)
+	events := []Event{DealOver{Result: result}}
+
+	if state.Deal < NumDeals(state) {
+		state.Phase = models.PhaseDealing
+		return events
+	}
+	state.Phase = models.PhaseOver
+	return append(events, GameOver{Standings: Standings(state)})
+}
+
+func trickComplete(state *models.GameState) bool {
+	for _, player := range state.Players() {
+		if player.PlayedCard == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func dealFinished(state *models.GameState) bool {
+	for _, player := range state.Players() {
+		if len(player.Hand) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func advanceTurn(state *models.GameState) {
+	state.Turn = state.Turn%len(state.Players()) + 1
+}
+
+// ************************** SEATS ********************************************
+
+// CurrentPlayer returns the player whose turn it is, or nil if Turn does not
+// point at a seat.
+func CurrentPlayer(state *models.GameState) *models.Player {
+	players := state.Players()
+	if state.Turn < 1 || state.Turn > len(players) {
+		return nil
+	}
+	return players[state.Turn-1]
+}
+
+// PlayerByID returns the seat held by playerID, or nil.
+func PlayerByID(state *models.GameState, playerID string) *models.Player {
+	for _, player := range state.Players() {
+		if player.ID == playerID {
+			return player
+		}
+	}
+	return nil
+}
+
+func cardIndex(hand []models.Card, card models.Card) int {
+	for i := range hand {
+		if hand[i] == card {
+			return i
+		}
+	}
+	return -1
+}
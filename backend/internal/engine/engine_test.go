@@ -0,0 +1,371 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"dealer-backend/internal/models"
+)
+
+func card(rank models.Rank, suit models.Suit) models.Card {
+	return models.Card{Rank: rank, Suit: suit}
+}
+
+// leadHeart deals small hands and has a lead the king of hearts, leaving b
+// to play
+func leadHeart(t *testing.T) models.GameState {
+	t.Helper()
+	state := models.GameState{
+		Seats: models.NewSeats([]string{"a", "b"}),
+		Turn:  1,
+		Phase: models.PhaseDealing,
+	}
+	hands := [][]models.Card{
+		{card(models.King, models.Hearts), card(models.Two, models.Clubs)},
+		{card(models.Ace, models.Hearts), card(models.Nine, models.Hearts), card(models.Three, models.Spades)},
+	}
+	actions := []Action{
+		Deal{Hands: hands},
+		Bid{PlayerID: "a", Amount: 1},
+		Bid{PlayerID: "b", Amount: 1},
+		PlayCard{PlayerID: "a", Card: hands[0][0]},
+	}
+	for _, action := range actions {
+		next, _, err := Apply(state, action)
+		if err != nil {
+			t.Fatalf("%T: %v", action, err)
+		}
+		state = next
+	}
+	return state
+}
+
+func TestTimeoutAutoPlaysLowestLegalCard(t *testing.T) {
+	state := leadHeart(t)
+
+	// Strict rules make b beat the king, so the ace is the only legal card
+	next, events, err := Apply(state, Timeout{PlayerID: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auto, ok := events[0].(AutoPlayed)
+	if !ok || auto.Card != card(models.Ace, models.Hearts) {
+		t.Fatalf("first event = %#v, want the ace of hearts auto-played", events[0])
+	}
+	if _, ok := events[1].(CardPlayed); !ok {
+		t.Errorf("second event = %#v, want CardPlayed", events[1])
+	}
+	if next.Scores["b"] != 1 {
+		t.Errorf("trick was not completed by the auto-played card: %v", next.Scores)
+	}
+
+	// Without must-beat the lowest heart goes
+	state.Rules = models.CasualCallBreak
+	_, events, err = Apply(state, Timeout{PlayerID: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := events[0].(AutoPlayed).Card; got != card(models.Nine, models.Hearts) {
+		t.Errorf("auto-played %v, want the nine of hearts", got)
+	}
+}
+
+func TestTimeoutPlaysAGivenLegalChoice(t *testing.T) {
+	state := leadHeart(t)
+	state.Rules = models.CasualCallBreak
+
+	choice := card(models.Ace, models.Hearts)
+	_, events, err := Apply(state, Timeout{PlayerID: "b", Card: &choice})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := events[0].(AutoPlayed).Card; got != choice {
+		t.Errorf("auto-played %v, want the chosen %v", got, choice)
+	}
+
+	// An illegal choice falls back to the lowest legal card
+	illegal := card(models.Three, models.Spades)
+	_, events, err = Apply(state, Timeout{PlayerID: "b", Card: &illegal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := events[0].(AutoPlayed).Card; got != card(models.Nine, models.Hearts) {
+		t.Errorf("auto-played %v for an illegal choice, want the nine of hearts", got)
+	}
+}
+
+// dealThree deals three cards to each of a, b and c, with b dealing
+func dealThree(t *testing.T, rules models.RuleSet) models.GameState {
+	t.Helper()
+	state := models.GameState{
+		Seats:  models.NewSeats([]string{"a", "b", "c"}),
+		Dealer: 2,
+		Phase:  models.PhaseDealing,
+		Rules:  rules,
+	}
+	hands := make([][]models.Card, 3)
+	for i, suit := range []models.Suit{models.Hearts, models.Clubs, models.Spades} {
+		hands[i] = []models.Card{card(models.Two, suit), card(models.Three, suit), card(models.Four, suit)}
+	}
+	next, _, err := Apply(state, Deal{Hands: hands})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return next
+}
+
+func TestBiddingGoesRoundFromLeftOfTheDealer(t *testing.T) {
+	state := dealThree(t, models.StrictCallBreak)
+	if got := state.BidOrder(); len(got) != 3 || got[0] != "c" || got[1] != "a" || got[2] != "b" {
+		t.Fatalf("bid order = %v, want [c a b]", got)
+	}
+
+	if _, _, err := Apply(state, Bid{PlayerID: "a", Amount: 1}); err != ErrNotYourTurn {
+		t.Errorf("bid out of turn: err = %v, want %v", err, ErrNotYourTurn)
+	}
+	for _, playerID := range []string{"c", "a", "b"} {
+		next, _, err := Apply(state, Bid{PlayerID: playerID, Amount: 1})
+		if err != nil {
+			t.Fatalf("%s bid: %v", playerID, err)
+		}
+		state = next
+	}
+	if state.Phase != models.PhasePlaying || state.Turn != 3 {
+		t.Errorf("after bidding phase = %q, turn = %d; want playing with c to lead", state.Phase, state.Turn)
+	}
+}
+
+func TestBidsOutsideTheLimitsAreRejected(t *testing.T) {
+	rules := models.StrictCallBreak
+	rules.MaxBid = 2
+	state := dealThree(t, rules)
+
+	for amount, want := range map[int]error{-1: ErrBidTooLow, 0: ErrBidTooLow, 1: nil, 2: nil, 3: ErrBidTooHigh, 14: ErrBidTooHigh} {
+		if _, _, err := Apply(state, Bid{PlayerID: "c", Amount: amount}); err != want {
+			t.Errorf("bid %d: err = %v, want %v", amount, err, want)
+		}
+	}
+}
+
+func TestDealerHookKeepsTheTotalOffTheTricks(t *testing.T) {
+	for _, rules := range []models.RuleSet{models.StrictCallBreak, models.HookCallBreak} {
+		state := dealThree(t, rules)
+		for _, bid := range []Bid{{PlayerID: "c", Amount: 1}, {PlayerID: "a", Amount: 1}} {
+			next, _, err := Apply(state, bid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			state = next
+		}
+
+		var want error
+		if rules.DealerHook {
+			want = ErrBidMakesTotal
+		}
+		if _, _, err := Apply(state, Bid{PlayerID: "b", Amount: 1}); err != want {
+			t.Errorf("%s: dealer making the total: err = %v, want %v", rules.Variant, err, want)
+		}
+		next, events, err := Apply(state, Timeout{PlayerID: "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantBid := 1
+		if rules.DealerHook {
+			wantBid = 2
+		}
+		if auto, ok := events[0].(AutoBid); !ok || auto.Amount != wantBid || next.Bids["b"] != wantBid {
+			t.Errorf("%s: timed out dealer got %#v and bid %d, want %d", rules.Variant, events[0], next.Bids["b"], wantBid)
+		}
+	}
+}
+
+func TestDealerRotatesEveryDeal(t *testing.T) {
+	state := models.GameState{
+		Seats:    models.NewSeats([]string{"a", "b", "c"}),
+		Phase:    models.PhaseDealing,
+		NumDeals: 4,
+	}
+	hands := [][]models.Card{
+		{card(models.Two, models.Hearts)},
+		{card(models.Three, models.Hearts)},
+		{card(models.Four, models.Hearts)},
+	}
+
+	apply := func(action Action) []Event {
+		t.Helper()
+		next, events, err := Apply(state, action)
+		if err != nil {
+			t.Fatalf("deal %d: %T: %v", state.Deal, action, err)
+		}
+		state = next
+		return events
+	}
+
+	// With no dealer set the last seat deals first
+	for i, dealer := range []string{"c", "a", "b", "c"} {
+		events := apply(Deal{Hands: hands})
+		if started := events[0].(DealStarted); started.Dealer != dealer {
+			t.Errorf("deal %d dealt by %s, want %s", i+1, started.Dealer, dealer)
+		}
+		order := state.BidOrder()
+		if order[len(order)-1] != dealer {
+			t.Errorf("deal %d bid order %v does not end with the dealer %s", i+1, order, dealer)
+		}
+		for _, playerID := range order {
+			apply(Bid{PlayerID: playerID, Amount: 1})
+		}
+		if leader := CurrentPlayer(&state); leader.ID != order[0] {
+			t.Errorf("deal %d led by %s, want %s", i+1, leader.ID, order[0])
+		}
+		for range order {
+			apply(Timeout{PlayerID: CurrentPlayer(&state).ID})
+		}
+	}
+	if state.Phase != models.PhaseOver {
+		t.Errorf("phase %q after the last deal, want over", state.Phase)
+	}
+}
+
+func TestRedealIsDealtAgainByTheSameDealer(t *testing.T) {
+	state := dealThree(t, models.StrictCallBreak)
+
+	// c holds spades but no face cards
+	next, events, err := Apply(state, Redeal{PlayerID: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if misdeal, ok := events[0].(Misdeal); !ok || misdeal.Condition != models.MisdealNoFaces || !misdeal.Requested {
+		t.Errorf("events = %#v, want a requested no-faces misdeal", events)
+	}
+	if next.Phase != models.PhaseDealing || len(next.Seats[0].Hand) != 0 {
+		t.Fatalf("hands were not thrown in: phase %q, hand %v", next.Phase, next.Seats[0].Hand)
+	}
+	next, _, err = Apply(next, Deal{Hands: [][]models.Card{{card(models.King, models.Spades)}, {card(models.Two, models.Spades)}, {card(models.Ace, models.Spades)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Deal != 1 || next.Dealer != 2 {
+		t.Errorf("redeal is deal %d dealt by seat %d, want deal 1 by seat 2", next.Deal, next.Dealer)
+	}
+
+	casual := dealThree(t, models.CasualCallBreak)
+	if _, _, err := Apply(casual, Redeal{PlayerID: "c"}); err != ErrNoMisdeal {
+		t.Errorf("redeal without misdeal rules: err = %v, want %v", err, ErrNoMisdeal)
+	}
+	bid, _, err := Apply(state, Bid{PlayerID: "c", Amount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Apply(bid, Redeal{PlayerID: "a"}); err != ErrBiddingStarted {
+		t.Errorf("redeal after a bid: err = %v, want %v", err, ErrBiddingStarted)
+	}
+}
+
+func TestAutoRedealThrowsInMisdealtHands(t *testing.T) {
+	rules := models.StrictCallBreak
+	rules.Misdeals = []string{models.MisdealNoSpades}
+	rules.AutoRedeal = true
+	state := models.GameState{
+		Seats: models.NewSeats([]string{"a", "b"}),
+		Phase: models.PhaseDealing,
+		Rules: rules,
+	}
+
+	next, events, err := Apply(state, Deal{Hands: [][]models.Card{{card(models.Two, models.Spades)}, {card(models.Two, models.Hearts)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	misdeal, ok := events[len(events)-1].(Misdeal)
+	if !ok || misdeal.PlayerID != "b" || misdeal.Reason != "no spades" || misdeal.Requested {
+		t.Errorf("events = %#v, want b's hand thrown in for having no spades", events)
+	}
+	if next.Phase != models.PhaseDealing {
+		t.Errorf("phase %q after a misdeal, want dealing", next.Phase)
+	}
+
+	next, _, err = Apply(next, Deal{Hands: [][]models.Card{{card(models.Two, models.Spades)}, {card(models.Three, models.Spades)}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Phase != models.PhaseBidding || next.Deal != 1 {
+		t.Errorf("after the redeal phase %q, deal %d; want bidding on deal 1", next.Phase, next.Deal)
+	}
+}
+
+// eventNames lists the names of events, to compare them in order
+func eventNames(events []Event) []string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = event.Name()
+	}
+	return names
+}
+
+func TestADealGoesFromDealingToScoring(t *testing.T) {
+	state := models.GameState{
+		Seats:    models.NewSeats([]string{"a", "b"}),
+		Phase:    models.PhaseDealing,
+		NumDeals: 2,
+	}
+	hands := [][]models.Card{
+		{card(models.Ace, models.Hearts), card(models.Two, models.Clubs)},
+		{card(models.King, models.Hearts), card(models.Three, models.Clubs)},
+	}
+
+	steps := []struct {
+		action Action
+		events []string
+		phase  models.Phase
+	}{
+		{Deal{Hands: hands}, []string{"dealstarted"}, models.PhaseBidding},
+		{Bid{PlayerID: "a", Amount: 1}, []string{"bidplaced"}, models.PhaseBidding},
+		{Bid{PlayerID: "b", Amount: 1}, []string{"bidplaced", "biddingcomplete"}, models.PhasePlaying},
+		{PlayCard{PlayerID: "a", Card: hands[0][0]}, []string{"cardplayed"}, models.PhasePlaying},
+		{PlayCard{PlayerID: "b", Card: hands[1][0]}, []string{"cardplayed", "trickwon"}, models.PhasePlaying},
+		{PlayCard{PlayerID: "a", Card: hands[0][1]}, []string{"cardplayed"}, models.PhasePlaying},
+		{PlayCard{PlayerID: "b", Card: hands[1][1]}, []string{"cardplayed", "trickwon", "dealover"}, models.PhaseDealing},
+	}
+
+	var last []Event
+	for _, step := range steps {
+		before := state.Clone()
+		next, events, err := Apply(state, step.action)
+		if err != nil {
+			t.Fatalf("%#v: %v", step.action, err)
+		}
+		if got := eventNames(events); strings.Join(got, ",") != strings.Join(step.events, ",") {
+			t.Errorf("%#v: events %v, want %v", step.action, got, step.events)
+		}
+		if next.Phase != step.phase {
+			t.Errorf("%#v: phase %q, want %q", step.action, next.Phase, step.phase)
+		}
+		if !reflect.DeepEqual(state, before) {
+			t.Errorf("%#v: Apply changed the state it was given", step.action)
+		}
+		state, last = next, events
+	}
+
+	// a won the first trick and b the second, so both made their bid
+	if won := last[1].(TrickWon); won.PlayerID != "b" {
+		t.Errorf("last trick won by %s, want b", won.PlayerID)
+	}
+	result := last[2].(DealOver).Result
+	if result.Points["a"] != 1 || result.Points["b"] != 1 {
+		t.Errorf("deal scored %v, want a bid made by each", result.Points)
+	}
+	if state.Deal != 1 || len(state.History) != 1 {
+		t.Errorf("after the deal: deal %d with %d results, want the first deal scored", state.Deal, len(state.History))
+	}
+
+	// Step applies in place, with the same outcome
+	inPlace := models.GameState{Seats: models.NewSeats([]string{"a", "b"}), Phase: models.PhaseDealing, NumDeals: 1}
+	for _, step := range steps {
+		if _, err := Step(&inPlace, step.action); err != nil {
+			t.Fatalf("step %#v: %v", step.action, err)
+		}
+	}
+	if inPlace.Phase != models.PhaseOver || inPlace.Totals["a"] != 1 {
+		t.Errorf("one deal match stepped to phase %q with totals %v, want over at 1 each", inPlace.Phase, inPlace.Totals)
+	}
+}
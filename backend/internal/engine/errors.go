@@ -0,0 +1,23 @@
+package engine
+
+import "errors"
+
+// Errors returned by Apply when an action breaks the rules. The state passed
+// to Apply is left untouched when one of these is returned.
+var (
+	ErrUnknownPlayer  = errors.New("player is not seated in this game")
+	ErrBadDeal        = errors.New("deal does not have one hand per seat")
+	ErrWrongPhase     = errors.New("action not allowed in this phase of the game")
+	ErrNotYourTurn    = errors.New("not your turn")
+	ErrAlreadyBid     = errors.New("player has already bid")
+	ErrBiddingStarted = errors.New("bidding has already started")
+	ErrNoMisdeal      = errors.New("hand does not allow a redeal")
+	ErrBidTooLow      = errors.New("bid is below the minimum")
+	ErrBidTooHigh     = errors.New("bid is above the maximum")
+	ErrBidMakesTotal  = errors.New("the dealer may not make the bids add up to the number of tricks")
+	ErrAlreadyPlayed  = errors.New("player already has a card on the table")
+	ErrCardNotInHand  = errors.New("card is not in the player's hand")
+	ErrMustFollowSuit = errors.New("player must follow suit")
+	ErrMustBeat       = errors.New("player must play a card that beats the winning card")
+	ErrMustTrump      = errors.New("player must trump with a spade")
+)
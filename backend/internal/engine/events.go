@@ -0,0 +1,83 @@
+package engine
+
+import "dealer-backend/internal/models"
+
+// Event describes something that happened as a result of an action. Callers
+// use them to decide what to broadcast.
+type Event interface {
+	Name() string
+}
+
+// DealStarted is emitted when new hands have been dealt.
+type DealStarted struct {
+	Deal   int
+	Dealer string // Player who dealt, the seat on their left bids and leads first
+}
+
+// Misdeal is emitted when the hands were thrown in, either because a player
+// asked for a redeal or, with AutoRedeal, right after the deal. The game goes
+// back to dealing; the same dealer deals the same deal again.
+type Misdeal struct {
+	PlayerID  string // Player whose hand was misdealt
+	Condition string
+	Reason    string
+	Requested bool // The player asked for the redeal
+}
+
+// BidPlaced is emitted for every accepted bid.
+type BidPlaced struct {
+	PlayerID string
+	Amount   int
+}
+
+// AutoBid is emitted when a bid was placed for a player whose bidding timer
+// ran out. It is followed by the BidPlaced event for the same bid.
+type AutoBid struct {
+	PlayerID string
+	Amount   int
+}
+
+// BiddingComplete is emitted once every seat has bid and play can start.
+type BiddingComplete struct{}
+
+// CardPlayed is emitted when a card is put on the table.
+type CardPlayed struct {
+	PlayerID string
+	Card     models.Card
+}
+
+// AutoPlayed is emitted when a card was played for a player whose turn timed
+// out. It is followed by the CardPlayed event for the same card.
+type AutoPlayed struct {
+	PlayerID string
+	Card     models.Card
+}
+
+// TrickWon is emitted when the last card of a trick is played.
+type TrickWon struct {
+	PlayerID string
+	Cards    []models.PlayedCardMessage
+}
+
+// DealOver is emitted when the last trick of a deal has been played and the
+// deal has been scored.
+type DealOver struct {
+	Result models.DealResult
+}
+
+// GameOver is emitted after the last deal of the match, with the final
+// standings.
+type GameOver struct {
+	Standings []models.Standing
+}
+
+func (DealStarted) Name() string     { return "dealstarted" }
+func (Misdeal) Name() string         { return "misdeal" }
+func (BidPlaced) Name() string       { return "bidplaced" }
+func (AutoBid) Name() string         { return "autobid" }
+func (BiddingComplete) Name() string { return "biddingcomplete" }
+func (CardPlayed) Name() string      { return "cardplayed" }
+func (AutoPlayed) Name() string      { return "autoplayed" }
+func (TrickWon) Name() string        { return "trickwon" }
+func (DealOver) Name() string        { return "dealover" }
+func (GameOver) Name() string        { return "gameover" }
@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+
+	"dealer-backend/internal/models"
+)
+
+// Kinds of log entries. Start, deal, redeal, bid, play and timeout are the inputs
+// Replay needs; the others record what the engine decided and are kept for
+// debugging and spectators.
+const (
+	EntryStart    = "start"
+	EntryDeal     = "deal"
+	EntryRedeal   = "redeal"
+	EntryBid      = "bid"
+	EntryPlay     = "play"
+	EntryTimeout  = "timeout"
+	EntryMisdeal  = "misdeal"
+	EntryTrickWon = "trickwon"
+	EntryDealOver = "dealover"
+	EntryGameOver = "gameover"
+)
+
+// LogEntry is one record of a game's event log.
+type LogEntry struct {
+	Seq       int                        `json:"seq"`
+	Kind      string                     `json:"kind"`
+	PlayerID  string                     `json:"playerId,omitempty"`
+	Card      *models.Card               `json:"card,omitempty"`
+	Amount    int                        `json:"amount,omitempty"`
+	Seed      models.Seed                `json:"seed,omitempty"`
+	Reason    string                     `json:"reason,omitempty"` // Why the hands were thrown in, misdeal entries only
+	State     *models.GameState          `json:"state,omitempty"`  // Initial state, start entries only
+	Cards     []models.PlayedCardMessage `json:"cards,omitempty"`
+	Result    *models.DealResult         `json:"result,omitempty"`
+	Standings []models.Standing          `json:"standings,omitempty"`
+}
+
+// GameLog is the append-only event log of one game. It is not safe for
+// concurrent use; the room that owns the game owns its log.
+type GameLog struct {
+	entries []LogEntry
+}
+
+// NewGameLog starts a log for a game whose state before the first deal is
+// initial.
+func NewGameLog(initial models.GameState) *GameLog {
+	state := initial.Clone()
+	l := &GameLog{}
+	l.append(LogEntry{Kind: EntryStart, State: &state})
+	return l
+}
+
+// Record appends an applied action and the events it produced.
+func (l *GameLog) Record(action Action, events []Event) {
+	switch a := action.(type) {
+	case Deal:
+		l.append(LogEntry{Kind: EntryDeal, Seed: a.Seed})
+	case Redeal:
+		l.append(LogEntry{Kind: EntryRedeal, PlayerID: a.PlayerID})
+	case Bid:
+		l.append(LogEntry{Kind: EntryBid, PlayerID: a.PlayerID, Amount: a.Amount})
+	case PlayCard:
+		card := a.Card
+		l.append(LogEntry{Kind: EntryPlay, PlayerID: a.PlayerID, Card: &card})
+	case Timeout:
+		entry := LogEntry{Kind: EntryTimeout, PlayerID: a.PlayerID}
+		if a.Card != nil {
+			card := *a.Card
+			entry.Card = &card
+		}
+		l.append(entry)
+	}
+
+	for _, event := range events {
+		switch e := event.(type) {
+		case Misdeal:
+			l.append(LogEntry{Kind: EntryMisdeal, PlayerID: e.PlayerID, Reason: e.Reason})
+		case TrickWon:
+			l.append(LogEntry{Kind: EntryTrickWon, PlayerID: e.PlayerID, Cards: e.Cards})
+		case DealOver:
+			result := e.Result
+			l.append(LogEntry{Kind: EntryDealOver, Result: &result})
+		case GameOver:
+			l.append(LogEntry{Kind: EntryGameOver, Standings: e.Standings})
+		}
+	}
+}
+
+// Entries returns a copy of the log.
+func (l *GameLog) Entries() []LogEntry {
+	return append([]LogEntry(nil), l.entries...)
+}
+
+func (l *GameLog) append(entry LogEntry) {
+	entry.Seq = len(l.entries)
+	l.entries = append(l.entries, entry)
+}
+
+// Replay rebuilds the game state as it was right after the entry numbered
+// upTo, using nothing but the log. Deals are regenerated from their seeds
+// with the dealing configured in the start state. Turn timers are not
+// logged, so player health is not reproduced.
+func Replay(entries []LogEntry, upTo int) (models.GameState, error) {
+	if len(entries) == 0 || entries[0].Kind != EntryStart || entries[0].State == nil {
+		return models.GameState{}, fmt.Errorf("replay: log does not begin with a start entry")
+	}
+	if upTo < 0 || upTo >= len(entries) {
+		return models.GameState{}, fmt.Errorf("replay: entry %d out of range", upTo)
+	}
+
+	state := entries[0].State.Clone()
+	for _, entry := range entries[1 : upTo+1] {
+		var action Action
+		switch entry.Kind {
+		case EntryDeal:
+			action = Deal{Seed: entry.Seed, Hands: state.Dealing.Hands(len(state.Seats), entry.Seed)}
+		case EntryRedeal:
+			action = Redeal{PlayerID: entry.PlayerID}
+		case EntryBid:
+			action = Bid{PlayerID: entry.PlayerID, Amount: entry.Amount}
+		case EntryPlay:
+			if entry.Card == nil {
+				return state, fmt.Errorf("replay: play entry %d has no card", entry.Seq)
+			}
+			action = PlayCard{PlayerID: entry.PlayerID, Card: *entry.Card}
+		case EntryTimeout:
+			action = Timeout{PlayerID: entry.PlayerID, Card: entry.Card}
+		default:
+			continue
+		}
+
+		next, _, err := Apply(state, action)
+		if err != nil {
+			return state, fmt.Errorf("replay: entry %d (%s): %w", entry.Seq, entry.Kind, err)
+		}
+		state = next
+	}
+	return state, nil
+}
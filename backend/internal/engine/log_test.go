@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"dealer-backend/internal/models"
+)
+
+// playLoggedGame plays a full match with seeded deals, recording every action.
+// Every deal is thrown in once. It returns the log and the state right after
+// each entry.
+func playLoggedGame(t *testing.T) ([]LogEntry, map[int]models.GameState) {
+	t.Helper()
+	t.Cleanup(RegisterMisdealCondition("anyhand", MisdealCondition{Reason: "any hand", Holds: func([]models.Card) bool { return true }}))
+
+	rules := models.StrictCallBreak
+	rules.Misdeals = []string{"anyhand"}
+	state := models.GameState{
+		Seats:    models.NewSeats([]string{"p1", "p2", "p3", "p4"}),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: 2,
+		Dealing:  models.Dealing{Deck: "double", Packet: 3},
+		Rules:    rules,
+	}
+	log := NewGameLog(state)
+	states := map[int]models.GameState{0: state.Clone()}
+
+	seed := int64(42)
+	redeals := 0
+	for step := 0; state.Phase != models.PhaseOver; step++ {
+		var action Action
+		switch state.Phase {
+		case models.PhaseDealing:
+			seed++
+			action = Deal{Seed: models.SeedOf(seed), Hands: state.Dealing.Hands(len(state.Seats), models.SeedOf(seed))}
+		case models.PhaseBidding:
+			player := CurrentPlayer(&state)
+			if len(state.Bids) == 0 && redeals < state.Deal {
+				redeals++
+				action = Redeal{PlayerID: player.ID}
+				break
+			}
+			if step%7 == 0 {
+				action = Timeout{PlayerID: player.ID}
+				break
+			}
+			action = Bid{PlayerID: player.ID, Amount: 1 + step%4}
+		case models.PhasePlaying:
+			player := CurrentPlayer(&state)
+			if step%17 == 0 {
+				action = Timeout{PlayerID: player.ID}
+				break
+			}
+			legal := LegalCards(&state, player)
+			action = PlayCard{PlayerID: player.ID, Card: legal[step%len(legal)]}
+		}
+
+		next, events, err := Apply(state, action)
+		if err != nil {
+			t.Fatalf("step %d: %v", step, err)
+		}
+		state = next
+		log.Record(action, events)
+		states[len(log.Entries())-1] = state.Clone()
+	}
+	return log.Entries(), states
+}
+
+func TestReplayRebuildsEveryState(t *testing.T) {
+	entries, states := playLoggedGame(t)
+
+	// The log must survive being written out and read back
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded []LogEntry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	for index, want := range states {
+		got, err := Replay(decoded, index)
+		if err != nil {
+			t.Fatalf("replay to %d: %v", index, err)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Fatalf("replay to %d differs from the recorded game\n got %s\nwant %s", index, gotJSON, wantJSON)
+		}
+	}
+
+	misdeals := 0
+	for _, entry := range decoded {
+		if entry.Kind == EntryMisdeal && entry.Reason == "any hand" {
+			misdeals++
+		}
+	}
+	if misdeals != 2 {
+		t.Errorf("log has %d misdeals, want one per deal", misdeals)
+	}
+
+	last := decoded[len(decoded)-1]
+	if last.Kind != EntryGameOver {
+		t.Errorf("last entry is %q, want %q", last.Kind, EntryGameOver)
+	}
+}
+
+func TestReplayRejectsBadLogs(t *testing.T) {
+	if _, err := Replay(nil, 0); err == nil {
+		t.Error("replayed an empty log")
+	}
+
+	entries, _ := playLoggedGame(t)
+	if _, err := Replay(entries, len(entries)); err == nil {
+		t.Error("replayed past the end of the log")
+	}
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"sync"
+
+	"dealer-backend/internal/models"
+)
+
+// MisdealCondition is a kind of hand so poor that the rules may let it be
+// thrown in and the cards dealt again.
+type MisdealCondition struct {
+	Reason string // Shown to the players when the hands are thrown in
+	Holds  func(hand []models.Card) bool
+}
+
+// misdealConditions lists the conditions a RuleSet can name in Misdeals.
+// Variants needing another condition add it with RegisterMisdealCondition.
+// Rooms read it from their own goroutines, so it is only used under
+// misdealMu.
+var (
+	misdealMu         sync.RWMutex
+	misdealConditions = map[string]MisdealCondition{
+		models.MisdealNoSpades: {
+			Reason: "no spades",
+			Holds: func(hand []models.Card) bool {
+				return !hasSuit(hand, Trump)
+			},
+		},
+		models.MisdealNoFaces: {
+			Reason: "no face cards",
+			Holds: func(hand []models.Card) bool {
+				for _, card := range hand {
+					if card.Rank == models.Jack || card.Rank == models.Queen || card.Rank == models.King {
+						return false
+					}
+				}
+				return true
+			},
+		},
+	}
+)
+
+// RegisterMisdealCondition lets rule sets name condition in Misdeals. It
+// returns a function that takes the condition away again.
+func RegisterMisdealCondition(name string, condition MisdealCondition) (unregister func()) {
+	misdealMu.Lock()
+	defer misdealMu.Unlock()
+	misdealConditions[name] = condition
+	return func() {
+		misdealMu.Lock()
+		defer misdealMu.Unlock()
+		delete(misdealConditions, name)
+	}
+}
+
+// misdealCondition returns the condition registered as name
+func misdealCondition(name string) (MisdealCondition, bool) {
+	misdealMu.RLock()
+	defer misdealMu.RUnlock()
+	condition, known := misdealConditions[name]
+	return condition, known
+}
+
+// Misdealt returns the first of the rules' misdeal conditions that player's
+// hand meets, and whether there is one.
+func Misdealt(state *models.GameState, player *models.Player) (string, bool) {
+	for _, name := range Rules(state).Misdeals {
+		condition, known := misdealCondition(name)
+		if known && condition.Holds(player.Hand) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// MisdealReason returns the reason shown to the players for condition.
+func MisdealReason(condition string) string {
+	if known, ok := misdealCondition(condition); ok {
+		return known.Reason
+	}
+	return condition
+}
+
+// autoRedeal throws the hands in right after a deal when the rules say so and
+// one of them is misdealt.
+func autoRedeal(state *models.GameState) []Event {
+	if !Rules(state).AutoRedeal {
+		return nil
+	}
+	for _, player := range state.Players() {
+		if condition, misdealt := Misdealt(state, player); misdealt {
+			return []Event{throwIn(state, player, condition, false)}
+		}
+	}
+	return nil
+}
+
+func applyRedeal(state *models.GameState, redeal Redeal) ([]Event, error) {
+	if state.Phase != models.PhaseBidding {
+		return nil, ErrWrongPhase
+	}
+	player := PlayerByID(state, redeal.PlayerID)
+	if player == nil {
+		return nil, ErrUnknownPlayer
+	}
+	if len(state.Bids) > 0 {
+		return nil, ErrBiddingStarted
+	}
+	condition, misdealt := Misdealt(state, player)
+	if !misdealt {
+		return nil, ErrNoMisdeal
+	}
+	return []Event{throwIn(state, player, condition, true)}, nil
+}
+
+// throwIn collects the hands so the same dealer deals the same deal again
+func throwIn(state *models.GameState, player *models.Player, condition string, requested bool) Event {
+	for _, p := range state.Players() {
+		p.Hand = nil
+		p.Bid = 0
+	}
+	state.Bids = make(map[string]int)
+	state.Phase = models.PhaseDealing
+	state.Redeal = true
+	return Misdeal{PlayerID: player.ID, Condition: condition, Reason: MisdealReason(condition), Requested: requested}
+}
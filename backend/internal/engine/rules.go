@@ -0,0 +1,194 @@
+package engine
+
+import "dealer-backend/internal/models"
+
+// Trump is the suit that beats every other suit in Call Break.
+const Trump = models.Spades
+
+var rankOrder = map[models.Rank]int{
+	models.Two:   0,
+	models.Three: 1,
+	models.Four:  2,
+	models.Five:  3,
+	models.Six:   4,
+	models.Seven: 5,
+	models.Eight: 6,
+	models.Nine:  7,
+	models.Ten:   8,
+	models.Jack:  9,
+	models.Queen: 10,
+	models.King:  11,
+	models.Ace:   12,
+}
+
+// RankValue orders ranks from Two (0) to Ace (12).
+func RankValue(rank models.Rank) int {
+	return rankOrder[rank]
+}
+
+// Beats reports whether card beats best given the suit that was led.
+func Beats(card, best models.Card, trickSuit models.Suit) bool {
+	switch {
+	case card.Suit == best.Suit:
+		return RankValue(card.Rank) > RankValue(best.Rank)
+	case card.Suit == Trump:
+		return true
+	case best.Suit == Trump:
+		return false
+	default:
+		return card.Suit == trickSuit && best.Suit != trickSuit
+	}
+}
+
+// Rules returns the rule set of the game. A RuleSet without a variant name
+// means the game was created without one and plays by strict Call Break.
+func Rules(state *models.GameState) models.RuleSet {
+	if state.Rules.Variant == "" {
+		return models.StrictCallBreak
+	}
+	return state.Rules
+}
+
+// BidRange returns the lowest and highest bids player may make.
+func BidRange(state *models.GameState, player *models.Player) (int, int) {
+	rules := Rules(state)
+	low, high := rules.MinBid, len(player.Hand)
+	if low < 1 {
+		low = 1
+	}
+	if rules.MaxBid > 0 && rules.MaxBid < high {
+		high = rules.MaxBid
+	}
+	return low, high
+}
+
+// ValidateBid checks that player may bid amount, returning the rule it breaks
+// otherwise. Whose turn it is is not checked.
+func ValidateBid(state *models.GameState, player *models.Player, amount int) error {
+	low, high := BidRange(state, player)
+	if amount < low {
+		return ErrBidTooLow
+	}
+	if amount > high {
+		return ErrBidTooHigh
+	}
+	if !Rules(state).DealerHook || len(state.Bids) != len(state.Seats)-1 {
+		return nil
+	}
+
+	// The last bid may not make the total equal the number of tricks
+	total := amount
+	for _, bid := range state.Bids {
+		total += bid
+	}
+	if total == len(player.Hand) {
+		return ErrBidMakesTotal
+	}
+	return nil
+}
+
+// DefaultBid is the bid placed for a player who didn't bid in time: the
+// lowest one the rules allow.
+func DefaultBid(state *models.GameState, player *models.Player) int {
+	low, high := BidRange(state, player)
+	for amount := low; amount < high; amount++ {
+		if ValidateBid(state, player, amount) == nil {
+			return amount
+		}
+	}
+	return high
+}
+
+// ValidateCard checks that player may put card on the table, returning the
+// rule it breaks otherwise.
+func ValidateCard(state *models.GameState, player *models.Player, card models.Card) error {
+	// The leader may play anything
+	if state.TrickSuit == "" {
+		return nil
+	}
+
+	rules := Rules(state)
+	winner := TrickWinner(state)
+	var best *models.Card
+	if winner != nil {
+		best = winner.PlayedCard
+	}
+
+	if hasSuit(player.Hand, state.TrickSuit) {
+		if rules.MustFollowSuit && card.Suit != state.TrickSuit {
+			return ErrMustFollowSuit
+		}
+		if rules.MustBeat && best != nil && !Beats(card, *best, state.TrickSuit) && canBeat(player.Hand, state.TrickSuit, *best, state.TrickSuit) {
+			return ErrMustBeat
+		}
+		return nil
+	}
+
+	// Void in the led suit
+	if !rules.MustTrump || !hasSuit(player.Hand, Trump) {
+		return nil
+	}
+	if best == nil || best.Suit != Trump {
+		if card.Suit != Trump {
+			return ErrMustTrump
+		}
+		return nil
+	}
+
+	// A spade is already winning: overtrump if possible, otherwise the
+	// player is free to discard
+	if !canBeat(player.Hand, Trump, *best, state.TrickSuit) {
+		return nil
+	}
+	if card.Suit != Trump {
+		return ErrMustTrump
+	}
+	if rules.MustBeat && !Beats(card, *best, state.TrickSuit) {
+		return ErrMustBeat
+	}
+	return nil
+}
+
+// TrickWinner returns the player holding the best card on the table.
+func TrickWinner(state *models.GameState) *models.Player {
+	var winner *models.Player
+	for _, player := range state.Players() {
+		if player.PlayedCard == nil {
+			continue
+		}
+		if winner == nil || Beats(*player.PlayedCard, *winner.PlayedCard, state.TrickSuit) {
+			winner = player
+		}
+	}
+	return winner
+}
+
+// LegalCards returns the cards in the player's hand they may play now.
+func LegalCards(state *models.GameState, player *models.Player) []models.Card {
+	legal := make([]models.Card, 0, len(player.Hand))
+	for _, card := range player.Hand {
+		if ValidateCard(state, player, card) == nil {
+			legal = append(legal, card)
+		}
+	}
+	return legal
+}
+
+func hasSuit(hand []models.Card, suit models.Suit) bool {
+	for _, card := range hand {
+		if card.Suit == suit {
+			return true
+		}
+	}
+	return false
+}
+
+// canBeat reports whether hand holds a card of suit that beats best.
+func canBeat(hand []models.Card, suit models.Suit, best models.Card, trickSuit models.Suit) bool {
+	for _, card := range hand {
+		if card.Suit == suit && Beats(card, best, trickSuit) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"testing"
+
+	"dealer-backend/internal/models"
+)
+
+// trickSoFar deals hands to a, b and c, has everyone bid one and plays the
+// cards given in turn from a, who leads
+func trickSoFar(t *testing.T, rules models.RuleSet, hands [][]models.Card, played []models.Card) models.GameState {
+	t.Helper()
+	state := models.GameState{
+		Seats: models.NewSeats([]string{"a", "b", "c"}),
+		Phase: models.PhaseDealing,
+		Rules: rules,
+	}
+	actions := []Action{Deal{Hands: hands}}
+	for _, playerID := range []string{"a", "b", "c"} {
+		actions = append(actions, Bid{PlayerID: playerID, Amount: 1})
+	}
+	for i, card := range played {
+		actions = append(actions, PlayCard{PlayerID: []string{"a", "b", "c"}[i], Card: card})
+	}
+	for _, action := range actions {
+		next, _, err := Apply(state, action)
+		if err != nil {
+			t.Fatalf("%#v: %v", action, err)
+		}
+		state = next
+	}
+	return state
+}
+
+func TestInvalidPlaysAreRejected(t *testing.T) {
+	kingOfHearts := card(models.King, models.Hearts)
+	lead := []models.Card{kingOfHearts, card(models.Two, models.Diamonds)}
+	filler := []models.Card{card(models.Two, models.Clubs)}
+
+	tests := []struct {
+		name   string
+		rules  models.RuleSet
+		hands  [][]models.Card // Of a, b and c
+		played []models.Card   // In turn from a
+		card   models.Card     // Played next
+		want   error
+	}{
+		{
+			name:   "discarding while holding the led suit",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Hearts), card(models.Three, models.Clubs)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Three, models.Clubs),
+			want:   ErrMustFollowSuit,
+		},
+		{
+			name:   "following suit",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Hearts), card(models.Three, models.Clubs)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Five, models.Hearts),
+		},
+		{
+			name:   "ducking with a card that could win",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Ace, models.Hearts), card(models.Nine, models.Hearts)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Nine, models.Hearts),
+			want:   ErrMustBeat,
+		},
+		{
+			name:   "beating the winning card",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Ace, models.Hearts), card(models.Nine, models.Hearts)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Ace, models.Hearts),
+		},
+		{
+			name:   "following low when nothing beats",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Queen, models.Hearts), card(models.Nine, models.Hearts)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Nine, models.Hearts),
+		},
+		{
+			name:   "discarding when void with a spade in hand",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Three, models.Spades), card(models.Five, models.Clubs)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Five, models.Clubs),
+			want:   ErrMustTrump,
+		},
+		{
+			name:   "trumping when void",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Three, models.Spades), card(models.Five, models.Clubs)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Three, models.Spades),
+		},
+		{
+			name:   "discarding when void without a spade",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Clubs), card(models.Seven, models.Diamonds)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Five, models.Clubs),
+		},
+		{
+			name:   "undertrumping when able to overtrump",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Spades)}, {card(models.Three, models.Spades), card(models.Nine, models.Spades)}},
+			played: []models.Card{kingOfHearts, card(models.Five, models.Spades)},
+			card:   card(models.Three, models.Spades),
+			want:   ErrMustBeat,
+		},
+		{
+			name:   "discarding when able to overtrump",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Spades)}, {card(models.Nine, models.Spades), card(models.Four, models.Clubs)}},
+			played: []models.Card{kingOfHearts, card(models.Five, models.Spades)},
+			card:   card(models.Four, models.Clubs),
+			want:   ErrMustTrump,
+		},
+		{
+			name:   "discarding when unable to overtrump",
+			rules:  models.StrictCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Spades)}, {card(models.Three, models.Spades), card(models.Four, models.Clubs)}},
+			played: []models.Card{kingOfHearts, card(models.Five, models.Spades)},
+			card:   card(models.Four, models.Clubs),
+		},
+		{
+			name:   "casual rules allow ducking",
+			rules:  models.CasualCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Ace, models.Hearts), card(models.Nine, models.Hearts)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Nine, models.Hearts),
+		},
+		{
+			name:   "casual rules allow discarding a spade holder",
+			rules:  models.CasualCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Three, models.Spades), card(models.Five, models.Clubs)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Five, models.Clubs),
+		},
+		{
+			name:   "casual rules still ask to follow suit",
+			rules:  models.CasualCallBreak,
+			hands:  [][]models.Card{lead, {card(models.Five, models.Hearts), card(models.Three, models.Clubs)}, filler},
+			played: []models.Card{kingOfHearts},
+			card:   card(models.Three, models.Clubs),
+			want:   ErrMustFollowSuit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := trickSoFar(t, tt.rules, tt.hands, tt.played)
+			player := CurrentPlayer(&state)
+			next, _, err := Apply(state, PlayCard{PlayerID: player.ID, Card: tt.card})
+			if err != tt.want {
+				t.Fatalf("%s plays %v: err = %v, want %v", player.ID, tt.card, err, tt.want)
+			}
+			if err != nil && len(next.Seats[len(tt.played)].Hand) != len(tt.hands[len(tt.played)]) {
+				t.Errorf("rejected card left the hand of %s", player.ID)
+			}
+		})
+	}
+}
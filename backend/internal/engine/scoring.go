@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"dealer-backend/internal/models"
+)
+
+// NumDeals returns the number of deals in the match.
+func NumDeals(state *models.GameState) int {
+	if state.NumDeals > 0 {
+		return state.NumDeals
+	}
+	return models.DefaultNumDeals
+}
+
+// DealPoints scores one deal the Call Break way: making the bid earns the
+// bid plus a tenth of a point per overtrick, missing it costs the bid.
+func DealPoints(bid, tricks int) float64 {
+	if tricks < bid {
+		return -float64(bid)
+	}
+	return roundPoints(float64(bid) + float64(tricks-bid)/10)
+}
+
+// scoreDeal compares the bids against the tricks won, records the result in
+// the history and adds the points to the running totals.
+func scoreDeal(state *models.GameState) models.DealResult {
+	result := models.DealResult{
+		Deal:   state.Deal,
+		Bids:   make(map[string]int),
+		Tricks: make(map[string]int),
+		Points: make(map[string]float64),
+	}
+	if state.Totals == nil {
+		state.Totals = make(map[string]float64)
+	}
+
+	for _, player := range state.Players() {
+		bid := state.Bids[player.ID]
+		tricks := state.Scores[player.ID]
+		points := DealPoints(bid, tricks)
+
+		result.Bids[player.ID] = bid
+		result.Tricks[player.ID] = tricks
+		result.Points[player.ID] = points
+		state.Totals[player.ID] = roundPoints(state.Totals[player.ID] + points)
+	}
+
+	state.History = append(state.History, result)
+	return result
+}
+
+// Standings ranks the players by their cumulative totals. Tied players share
+// a rank.
+func Standings(state *models.GameState) []models.Standing {
+	standings := make([]models.Standing, 0, len(state.Players()))
+	for _, player := range state.Players() {
+		standings = append(standings, models.Standing{PlayerID: player.ID, Total: state.Totals[player.ID]})
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Total > standings[j].Total
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Total == standings[i-1].Total {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings
+}
+
+// roundPoints keeps totals at one decimal so repeated tenths don't drift.
+func roundPoints(points float64) float64 {
+	return math.Round(points*10) / 10
+}
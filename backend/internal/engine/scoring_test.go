@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"testing"
+
+	"dealer-backend/internal/models"
+)
+
+func TestDealPoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		bid, tricks int
+		want        float64
+	}{
+		{"bid made exactly", 3, 3, 3},
+		{"one overtrick", 3, 4, 3.1},
+		{"three overtricks", 2, 5, 2.3},
+		{"every trick", 1, 13, 2.2},
+		{"bid missed by one", 4, 3, -4},
+		{"no tricks", 5, 0, -5},
+	}
+	for _, tt := range tests {
+		if got := DealPoints(tt.bid, tt.tricks); got != tt.want {
+			t.Errorf("%s: bid %d, tricks %d scores %v, want %v", tt.name, tt.bid, tt.tricks, got, tt.want)
+		}
+	}
+}
+
+func TestDealsAddUpToTotals(t *testing.T) {
+	state := models.GameState{
+		Seats:  models.NewSeats([]string{"a", "b", "c"}),
+		Deal:   1,
+		Bids:   map[string]int{"a": 3, "b": 4, "c": 2},
+		Scores: map[string]int{"a": 5, "b": 3, "c": 2},
+	}
+	result := scoreDeal(&state)
+
+	want := map[string]float64{"a": 3.2, "b": -4, "c": 2}
+	for playerID, points := range want {
+		if result.Points[playerID] != points || state.Totals[playerID] != points {
+			t.Errorf("%s scored %v for a total of %v, want %v", playerID, result.Points[playerID], state.Totals[playerID], points)
+		}
+	}
+	if len(state.History) != 1 || state.History[0].Deal != 1 || state.History[0].Bids["b"] != 4 || state.History[0].Tricks["b"] != 3 {
+		t.Errorf("history = %+v, want the first deal's bids and tricks", state.History)
+	}
+
+	// The second deal adds on, in tenths that don't drift
+	state.Deal = 2
+	state.Bids = map[string]int{"a": 1, "b": 1, "c": 1}
+	state.Scores = map[string]int{"a": 2, "b": 1, "c": 0}
+	scoreDeal(&state)
+	want = map[string]float64{"a": 4.3, "b": -3, "c": 1}
+	for playerID, total := range want {
+		if state.Totals[playerID] != total {
+			t.Errorf("%s total %v after two deals, want %v", playerID, state.Totals[playerID], total)
+		}
+	}
+	if len(state.History) != 2 {
+		t.Errorf("history holds %d deals, want 2", len(state.History))
+	}
+}
+
+func TestStandingsRankByTotalAndShareTies(t *testing.T) {
+	state := models.GameState{
+		Seats:  models.NewSeats([]string{"a", "b", "c", "d"}),
+		Totals: map[string]float64{"a": 2.1, "b": 7.3, "c": 7.3, "d": -4},
+	}
+
+	got := Standings(&state)
+	want := []models.Standing{
+		{PlayerID: "b", Total: 7.3, Rank: 1},
+		{PlayerID: "c", Total: 7.3, Rank: 1},
+		{PlayerID: "a", Total: 2.1, Rank: 3},
+		{PlayerID: "d", Total: -4, Rank: 4},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("standings = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("place %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
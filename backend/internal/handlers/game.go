@@ -1,52 +1,36 @@
 package handlers
 
 import (
-	//"encoding/json"
+	"encoding/json"
+	"errors"
 	// "fmt"
-	//"dealer-backend/internal/auth"
+	"dealer-backend/internal/auth"
 	"dealer-backend/internal/config"
 	"dealer-backend/internal/services"
-	"io"
 	"net/http"
 )
 
 
+// StartHandler queues the authenticated player for a game and answers right
+// away with their ticket. The match is announced over the WebSocket.
 func StartHandler(w http.ResponseWriter, r *http.Request) {
-    playerID := r.URL.Query().Get("playerID")
-    services.StartMatchmaking(config.PlayerConnections, playerID)
-}
-
-func MoveHandler(w http.ResponseWriter, r *http.Request) {
-    switch r.Method {
-    case http.MethodPost:
-        // Parse `gameID` and `playerID` from the query parameters
-        gameID := r.URL.Query().Get("gameID")
-        playerID := r.URL.Query().Get("playerID")
-
-        if gameID == "" || playerID == "" {
-            http.Error(w, "gameID and playerID are required", http.StatusBadRequest)
-            return
-        }
-
-        // Read the move data (message body) from the request
-        message, err := io.ReadAll(r.Body)
-        if err != nil {
-            http.Error(w, "Failed to read move data", http.StatusBadRequest)
-            return
-        }
-        defer r.Body.Close()
-
-        // Call handlePlayerMove with parsed gameID, playerID, and move data
-        services.HandlePlayerMove(gameID, playerID, message)
-
-        w.WriteHeader(http.StatusOK)
-        w.Write([]byte("Move made"))
-    default:
-        http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+    claims, ok := auth.ClaimsFromContext(r.Context())
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
     }
-}
-
-
-
 
+    ticket, err := config.Matchmaker.Enqueue(claims.Username)
+    switch {
+    case errors.Is(err, services.ErrNotInLobby), errors.Is(err, services.ErrAlreadyPlaying):
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    case err != nil:
+        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+        return
+    }
 
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(ticket)
+}
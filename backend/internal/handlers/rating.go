@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"dealer-backend/internal/services"
+	"encoding/json"
+	"net/http"
+)
+
+// RatingHandler answers with a player's rating and the games that changed
+// it, oldest first. Players who have not finished a game have the initial
+// rating.
+func RatingHandler(w http.ResponseWriter, r *http.Request) {
+	playerID := r.PathValue("playerID")
+	if playerID == "" {
+		http.Error(w, "Missing player", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.Ratings.Get(playerID))
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"dealer-backend/internal/auth"
+	"dealer-backend/internal/config"
+	"dealer-backend/internal/protocol"
+	"dealer-backend/internal/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// CreateRoomHandler opens a private room hosted by the authenticated player,
+// configured by the settings in the body, if any. Everyone in the room hears
+// of changes over the WebSocket.
+func CreateRoomHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	settings, ok := readSettings(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := config.PrivateRooms.Create(claims.Username, settings)
+	if err != nil {
+		roomError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, room)
+}
+
+// JoinRoomHandler seats the authenticated player in the room of the join code
+func JoinRoomHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	room, err := config.PrivateRooms.Join(claims.Username, r.PathValue("code"))
+	if err != nil {
+		roomError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+// ConfigureRoomHandler replaces the settings of a room, for its host
+func ConfigureRoomHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := roomMember(w, r)
+	if !ok {
+		return
+	}
+	settings, ok := readSettings(w, r)
+	if !ok {
+		return
+	}
+
+	room, err := config.PrivateRooms.Configure(playerID, settings)
+	if err != nil {
+		roomError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+// LeaveRoomHandler takes the authenticated player out of a room
+func LeaveRoomHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := roomMember(w, r)
+	if !ok {
+		return
+	}
+	if err := config.PrivateRooms.Leave(playerID); err != nil {
+		roomError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// StartRoomHandler starts the game of a room, for its host. The match is
+// announced over the WebSocket.
+func StartRoomHandler(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := roomMember(w, r)
+	if !ok {
+		return
+	}
+
+	game, err := config.PrivateRooms.Start(playerID)
+	if err != nil {
+		roomError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"roomId": game.ID})
+}
+
+// roomMember returns the authenticated player, if they are in the room of
+// the join code in the path
+func roomMember(w http.ResponseWriter, r *http.Request) (string, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	room, in := config.PrivateRooms.RoomOf(claims.Username)
+	if !in || room.Code != services.NormalizeJoinCode(r.PathValue("code")) {
+		roomError(w, services.ErrNotInRoom)
+		return "", false
+	}
+	return claims.Username, true
+}
+
+// readSettings decodes the room settings in the body. An empty body keeps
+// the defaults.
+func readSettings(w http.ResponseWriter, r *http.Request) (protocol.RoomSettings, bool) {
+	var settings protocol.RoomSettings
+	if r.ContentLength == 0 {
+		return settings, true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid room settings: "+err.Error(), http.StatusBadRequest)
+		return settings, false
+	}
+	return settings, true
+}
+
+// roomError answers with the status matching a private room error
+func roomError(w http.ResponseWriter, err error) {
+	status := http.StatusConflict
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrNotHost):
+		status = http.StatusForbidden
+	case protocol.CodeFor(err) == protocol.CodeInvalidSettings:
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrMatchmakerStopped):
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand/v2"
+)
+
+// Composition describes which cards make up a deck
+type Composition struct {
+	Name   string
+	Ranks  []Rank // Lowest first, decides which cards trimming removes
+	Copies int    // Number of copies of every card, 1 when zero
+}
+
+var allRanks = []Rank{Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King, Ace}
+
+var (
+	StandardDeck = Composition{Name: "standard", Ranks: allRanks}
+	PiquetDeck   = Composition{Name: "piquet", Ranks: []Rank{Seven, Eight, Nine, Ten, Jack, Queen, King, Ace}}
+	DoubleDeck   = Composition{Name: "double", Ranks: allRanks, Copies: 2}
+)
+
+// Compositions are the decks a game can be configured with, by name
+var Compositions = map[string]Composition{
+	StandardDeck.Name: StandardDeck,
+	PiquetDeck.Name:   PiquetDeck,
+	DoubleDeck.Name:   DoubleDeck,
+}
+
+// Cards lists every card of the composition in a fixed order
+func (c Composition) Cards() []Card {
+	copies := c.Copies
+	if copies < 1 {
+		copies = 1
+	}
+
+	cards := make([]Card, 0, 4*len(c.Ranks)*copies)
+	for n := 0; n < copies; n++ {
+		for _, suit := range []Suit{Hearts, Diamonds, Clubs, Spades} {
+			for _, rank := range c.Ranks {
+				cards = append(cards, Card{Rank: rank, Suit: suit})
+			}
+		}
+	}
+	return cards
+}
+
+// ************************** DEALING STRATEGIES ********************************************
+
+// DealStrategy splits a shuffled deck into one hand per player
+type DealStrategy interface {
+	Deal(cards []Card, numPlayers int) [][]Card
+}
+
+// RoundRobin deals one card at a time to each player in turn
+type RoundRobin struct{}
+
+func (RoundRobin) Deal(cards []Card, numPlayers int) [][]Card {
+	hands := make([][]Card, numPlayers)
+	for i, card := range cards {
+		hands[i%numPlayers] = append(hands[i%numPlayers], card)
+	}
+	return hands
+}
+
+// Packets deals Size cards at a time to each player in turn. The last round
+// of packets is smaller when the hands don't divide into whole packets.
+type Packets struct {
+	Size int
+}
+
+func (p Packets) Deal(cards []Card, numPlayers int) [][]Card {
+	if p.Size < 1 {
+		return RoundRobin{}.Deal(cards, numPlayers)
+	}
+
+	hands := make([][]Card, numPlayers)
+	perHand := len(cards) / numPlayers
+	next := 0
+	for dealt := 0; dealt < perHand; dealt += p.Size {
+		size := p.Size
+		if dealt+size > perHand {
+			size = perHand - dealt
+		}
+		for i := range hands {
+			hands[i] = append(hands[i], cards[next:next+size]...)
+			next += size
+		}
+	}
+	return hands
+}
+
+// Dealing is how a game deals its hands. The zero value deals a standard
+// deck round-robin.
+type Dealing struct {
+	Deck   string `json:"deck"`   // Name of a Composition, standard when empty
+	Packet int    `json:"packet"` // Cards per packet, round-robin when zero
+}
+
+// Composition returns the deck configured by d
+func (d Dealing) Composition() Composition {
+	if composition, ok := Compositions[d.Deck]; ok {
+		return composition
+	}
+	return StandardDeck
+}
+
+// Strategy returns the dealing strategy configured by d
+func (d Dealing) Strategy() DealStrategy {
+	if d.Packet > 0 {
+		return Packets{Size: d.Packet}
+	}
+	return RoundRobin{}
+}
+
+// Hands shuffles a fresh deck with seed and deals it into numPlayers equal
+// hands. The same seed always deals the same hands.
+func (d Dealing) Hands(numPlayers int, seed Seed) [][]Card {
+	if numPlayers == 0 {
+		return [][]Card{}
+	}
+	var key [SeedSize]byte
+	copy(key[:], seed)
+	deck := NewDeck(d.Composition(), rand.NewChaCha8(key))
+	deck.Trim(numPlayers)
+	deck.Shuffle()
+	return deck.Deal(numPlayers, d.Strategy())
+}
+
+// ************************** DECK ********************************************
+
+// Deck is a pile of cards shuffled with its own random source
+type Deck struct {
+	Cards       []Card
+	composition Composition
+	rng         *rand.Rand
+}
+
+// NewDeck returns an unshuffled deck of the given composition. Pass a seeded
+// source to make shuffles repeatable, or CryptoSource for unpredictable ones.
+func NewDeck(composition Composition, source rand.Source) *Deck {
+	return &Deck{
+		Cards:       composition.Cards(),
+		composition: composition,
+		rng:         rand.New(source),
+	}
+}
+
+// Shuffle puts the cards in a random order
+func (d *Deck) Shuffle() {
+	d.rng.Shuffle(len(d.Cards), func(i, j int) {
+		d.Cards[i], d.Cards[j] = d.Cards[j], d.Cards[i]
+	})
+}
+
+// Trim removes the lowest non-spade cards (2C, 2D, 2H, 3C, ... for a standard
+// deck) until the deck splits evenly between numPlayers, so 3 players get 17
+// cards each and 5 players get 10.
+func (d *Deck) Trim(numPlayers int) {
+	remainder := len(d.Cards) % numPlayers
+	if remainder == 0 {
+		return
+	}
+
+	// Pick the cards to drop, lowest rank first
+	removed := make(map[Card]int, remainder)
+	for _, rank := range d.composition.Ranks {
+		for _, suit := range []Suit{Clubs, Diamonds, Hearts} {
+			for _, card := range d.Cards {
+				if remainder > 0 && card == (Card{Rank: rank, Suit: suit}) {
+					removed[card]++
+					remainder--
+				}
+			}
+		}
+	}
+
+	trimmed := make([]Card, 0, len(d.Cards)-remainder)
+	for _, card := range d.Cards {
+		if removed[card] > 0 {
+			removed[card]--
+			continue
+		}
+		trimmed = append(trimmed, card)
+	}
+	d.Cards = trimmed
+}
+
+// Deal splits the deck between numPlayers using strategy
+func (d *Deck) Deal(numPlayers int, strategy DealStrategy) [][]Card {
+	return strategy.Deal(d.Cards, numPlayers)
+}
+
+// ************************** RANDOMNESS ********************************************
+
+// cryptoSource is a rand.Source backed by crypto/rand. It cannot be seeded.
+type cryptoSource struct{}
+
+// CryptoSource returns a random source that cannot be predicted or replayed
+func CryptoSource() rand.Source {
+	return cryptoSource{}
+}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// SeedSize is the number of bytes in a seed made by NewSeed
+const SeedSize = 32
+
+// Seed is the key a deal is shuffled with. Decks are shuffled by ChaCha8
+// keyed with all of its bytes, so no two seeds are known to deal alike and
+// the hands can't be worked back from a few cards to the seed.
+type Seed []byte
+
+// NewSeed picks an unpredictable shuffle seed. Games deal from seeds rather
+// than from CryptoSource directly so each deal can be regenerated.
+func NewSeed() Seed {
+	seed := make(Seed, SeedSize)
+	if _, err := crand.Read(seed); err != nil {
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	return seed
+}
+
+// SeedOf returns the seed numbered n, for runs that must repeat such as
+// simulations and tests. Its deals are as easy to guess as n.
+func SeedOf(n int64) Seed {
+	return binary.LittleEndian.AppendUint64(nil, uint64(n))
+}
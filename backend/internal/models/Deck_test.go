@@ -0,0 +1,89 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeededDealsAreDeterministic(t *testing.T) {
+	dealing := Dealing{}
+	first := dealing.Hands(4, SeedOf(7))
+	if !reflect.DeepEqual(first, dealing.Hands(4, SeedOf(7))) {
+		t.Error("same seed dealt different hands")
+	}
+	if reflect.DeepEqual(first, dealing.Hands(4, SeedOf(8))) {
+		t.Error("different seeds dealt the same hands")
+	}
+}
+
+func TestSeedsApartByTheOldSourceModulusDealApart(t *testing.T) {
+	// math/rand's seeded source reduced seeds mod 2^31-1, so these dealt alike
+	const modulus = 1<<31 - 1
+	if reflect.DeepEqual(Dealing{}.Hands(4, SeedOf(7)), Dealing{}.Hands(4, SeedOf(7+modulus))) {
+		t.Error("seeds 2^31-1 apart dealt the same hands")
+	}
+	if seed := NewSeed(); len(seed) != SeedSize {
+		t.Errorf("NewSeed made %d bytes, want %d", len(seed), SeedSize)
+	}
+}
+
+func TestCompositionsDealEvenHands(t *testing.T) {
+	cases := []struct {
+		deck       string
+		numPlayers int
+		handSize   int
+	}{
+		{"standard", 4, 13},
+		{"standard", 3, 17},
+		{"standard", 5, 10},
+		{"piquet", 4, 8},
+		{"piquet", 3, 10},
+		{"double", 4, 26},
+		{"double", 6, 17},
+	}
+
+	for _, c := range cases {
+		hands := Dealing{Deck: c.deck}.Hands(c.numPlayers, SeedOf(1))
+		if len(hands) != c.numPlayers {
+			t.Fatalf("%s deck for %d dealt %d hands", c.deck, c.numPlayers, len(hands))
+		}
+		for i, hand := range hands {
+			if len(hand) != c.handSize {
+				t.Errorf("%s deck for %d: hand %d has %d cards, want %d", c.deck, c.numPlayers, i, len(hand), c.handSize)
+			}
+		}
+	}
+}
+
+func TestTrimDropsLowestNonSpades(t *testing.T) {
+	deck := NewDeck(DoubleDeck, CryptoSource())
+	deck.Trim(3) // 104 cards, two too many
+
+	counts := make(map[Card]int)
+	for _, card := range deck.Cards {
+		counts[card]++
+	}
+	if counts[Card{Rank: Two, Suit: Clubs}] != 0 || counts[Card{Rank: Two, Suit: Diamonds}] != 2 {
+		t.Errorf("expected both copies of 2C to be dropped, left %d 2C and %d 2D",
+			counts[Card{Rank: Two, Suit: Clubs}], counts[Card{Rank: Two, Suit: Diamonds}])
+	}
+	if counts[Card{Rank: Two, Suit: Spades}] != 2 {
+		t.Error("spades must never be trimmed")
+	}
+}
+
+func TestPacketsDealInBlocks(t *testing.T) {
+	cards := StandardDeck.Cards()
+	hands := Packets{Size: 5}.Deal(cards, 4)
+
+	// Each player gets two packets of 5, then a last packet of 3
+	if !reflect.DeepEqual(hands[1][:5], cards[5:10]) {
+		t.Errorf("second player's first packet = %v, want %v", hands[1][:5], cards[5:10])
+	}
+	if !reflect.DeepEqual(hands[0][5:10], cards[20:25]) {
+		t.Errorf("first player's second packet = %v, want %v", hands[0][5:10], cards[20:25])
+	}
+	if !reflect.DeepEqual(hands[3][10:], cards[49:52]) {
+		t.Errorf("last player's last packet = %v, want %v", hands[3][10:], cards[49:52])
+	}
+}
@@ -2,14 +2,6 @@ package models
 
 import (
 	"fmt"
-	"log"
-	"sync"
-	"time"
-
-	"encoding/json"
-	"math/rand"
-
-	"github.com/gorilla/websocket"
 )
 
 // Game stores the state of an active game
@@ -21,15 +13,117 @@ type Game struct {
 }
 
 type GameState struct {
-	Player1 Player `json:"player1"`
-	Player2 Player `json:"player2"`
-	Player3 Player `json:"player3"`
-	Player4 Player `json:"player4"`
-	Turn    int    `json:"turn"` // Keeps track of which player's turn it is
+	Seats   []Player `json:"seats"` // Players in turn order
+	Turn    int    `json:"turn"` // Position (1-based) of the seat whose turn it is to bid or play
+	Dealer  int    `json:"dealer"` // Position (1-based) of the dealer, the last seat when zero. It moves one seat left every deal.
 	TrickSuit Suit `json:"trick_suit"`
 	RoundWinner *Player `json:"round_winner,omitempty"`
 	Scores      map[string]int   `json:"scores"` // Track scores by player ID
 	Bids        map[string]int   `json:"bids"` 
+	Phase       Phase            `json:"phase"`
+	LastPlay    *PlayedCardMessage `json:"last_play,omitempty"` // Most recent card put on the table
+	Deal        int              `json:"deal"`      // Number of the deal being played, starting at 1
+	NumDeals    int              `json:"num_deals"` // Deals in the match, DefaultNumDeals when zero
+	Totals      map[string]float64 `json:"totals"`  // Cumulative points over the deals played so far
+	History     []DealResult     `json:"history"`
+	Rules       RuleSet          `json:"rules"`
+	Dealing     Dealing          `json:"dealing"`
+	Seed        Seed             `json:"seed"` // Shuffle seed of the current deal, never sent to players
+	Redeal      bool             `json:"redeal"` // The hands were thrown in, the next deal is dealt again by the same dealer
+}
+
+// DefaultNumDeals is the length of a match when none is configured
+const DefaultNumDeals = 5
+
+// DealResult is the outcome of one deal, bids against tricks won
+type DealResult struct {
+	Deal   int                `json:"deal"`
+	Bids   map[string]int     `json:"bids"`
+	Tricks map[string]int     `json:"tricks"`
+	Points map[string]float64 `json:"points"`
+}
+
+// Standing is a player's place in the match once all deals are played
+type Standing struct {
+	PlayerID string  `json:"playerId"`
+	Total    float64 `json:"total"`
+	Rank     int     `json:"rank"`
+}
+
+// Phase is the stage of the game the rules engine is in
+type Phase string
+
+const (
+	PhaseDealing Phase = "dealing" // Waiting for the next hands to be dealt
+	PhaseBidding Phase = "bidding"
+	PhasePlaying Phase = "playing"
+	PhaseOver    Phase = "over"
+)
+
+// Table sizes supported by the dealing and turn logic
+const (
+	MinPlayers = 2
+	MaxPlayers = 6
+)
+
+// NewSeats seats the given players in order with a full turn timer
+func NewSeats(playerIDs []string) []Player {
+	seats := make([]Player, len(playerIDs))
+	for i, playerID := range playerIDs {
+		seats[i] = Player{ID: playerID, Health: 100}
+	}
+	return seats
+}
+
+// Players returns pointers to the seats in turn order
+func (gs *GameState) Players() []*Player {
+	players := make([]*Player, len(gs.Seats))
+	for i := range gs.Seats {
+		players[i] = &gs.Seats[i]
+	}
+	return players
+}
+
+// DealerPosition returns the position (1-based) of the seat that deals
+func (gs *GameState) DealerPosition() int {
+	if gs.Dealer < 1 || gs.Dealer > len(gs.Seats) {
+		return len(gs.Seats)
+	}
+	return gs.Dealer
+}
+
+// BidOrder lists the players in the order they bid, starting with the seat
+// left of the dealer
+func (gs *GameState) BidOrder() []string {
+	order := make([]string, len(gs.Seats))
+	dealer := gs.DealerPosition()
+	for i := range order {
+		order[i] = gs.Seats[(dealer+i)%len(gs.Seats)].ID
+	}
+	return order
+}
+
+// Clone returns a deep copy of the game state, so the copy can be changed
+// without affecting the original
+func (gs GameState) Clone() GameState {
+	clone := gs
+	clone.Seats = make([]Player, len(gs.Seats))
+	for i := range gs.Seats {
+		clone.Seats[i] = gs.Seats[i].clone()
+	}
+	if gs.RoundWinner != nil {
+		winner := gs.RoundWinner.clone()
+		clone.RoundWinner = &winner
+	}
+	if gs.LastPlay != nil {
+		lastPlay := *gs.LastPlay
+		clone.LastPlay = &lastPlay
+	}
+	clone.Scores = copyIntMap(gs.Scores)
+	clone.Bids = copyIntMap(gs.Bids)
+	clone.Totals = copyFloatMap(gs.Totals)
+	clone.History = append([]DealResult(nil), gs.History...)
+	return clone
 }
 
 type Player struct {
@@ -41,6 +135,17 @@ type Player struct {
 	Score int `json:"score"`
 }
 
+func (p Player) clone() Player {
+	if p.Hand != nil {
+		p.Hand = append(make([]Card, 0, len(p.Hand)), p.Hand...)
+	}
+	if p.PlayedCard != nil {
+		card := *p.PlayedCard
+		p.PlayedCard = &card
+	}
+	return p
+}
+
 // RemovePlayedCard removes the played card from the player's hand
 func (p *Player) RemovePlayedCard() {
 	fmt.Println("Remove requested....")
@@ -124,217 +229,27 @@ type PlayedCardMessage struct {
 }
 
 
-// Struct to hold the player connections
-type PlayerConnections struct {
-	mu      sync.RWMutex
-	players map[string]*websocket.Conn
-}
-
-// Create a new PlayerConnections object
-func NewPlayerConnections() *PlayerConnections {
-	return &PlayerConnections{
-		players: make(map[string]*websocket.Conn),
-	}
-}
-
-func (pc *PlayerConnections) PingPlayerConnection(playerID string) {
-	conn, exists := pc.GetPlayerConnection(playerID)
-	if !exists {
-		return
-	}
-
-	// Set up a ping/pong mechanism
-	conn.SetPongHandler(func(appData string) error {
-		// Pong received, keep the connection active
-		return nil
-	})
-
-	go func() {
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			// Check if the connection is still valid before sending a ping
-			if _, exists := pc.GetPlayerConnection(playerID); !exists {
-				log.Println("Connection no longer exists, stopping ping.")
-				return
-			}
-			
-			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
-				log.Println("Ping failed, removing player:", playerID)
-				pc.RemovePlayer(playerID)
-				return
-			}
-		}
-	}()
-}
-
 func (gs *GameState) GetPlayerPosition(p Player) int {
-    switch {
-    case gs.Player1.ID == p.ID:
-        return 1
-    case gs.Player2.ID == p.ID:
-        return 2
-    case gs.Player3.ID == p.ID:
-        return 3
-    case gs.Player4.ID == p.ID:
-        return 4
-    default:
-        return 0
+    for i := range gs.Seats {
+        if gs.Seats[i].ID == p.ID {
+            return i + 1
+        }
     }
-}
-
-func (pc *PlayerConnections) AddPlayer(playerID string, conn *websocket.Conn) {
-	fmt.Println("Starting AddPlayer for", playerID)
-	pc.mu.Lock()
-	defer pc.mu.Unlock()
-	fmt.Println("Acquired lock for", playerID)
-	pc.players[playerID] = conn
-	fmt.Println("Added", playerID, "to players map")
-	fmt.Println("About to call broadcastPlayerList for", playerID)
-	go pc.broadcastPlayerList()  // Call broadcastPlayerList in a new goroutine
-	fmt.Println("Finished AddPlayer for", playerID)
-}
-
-func (pc *PlayerConnections) RemovePlayer(playerID string) {
-	fmt.Println("removing player", playerID)
-	pc.mu.Lock()
-	defer pc.mu.Unlock()
-	delete(pc.players, playerID)
-	pc.broadcastPlayerList()
-}
-
-func (pc *PlayerConnections) GetPlayerList() []string {
-	fmt.Println("Entering GetPlayerList")
-	pc.mu.RLock()
-	fmt.Println("Acquired read lock in GetPlayerList")
-	
-	// Create a copy of the players map
-	playersCopy := make(map[string]struct{})
-	for playerID := range pc.players {
-		playersCopy[playerID] = struct{}{}
-		fmt.Printf("Copied player: %s\n", playerID)
-	}
-	pc.mu.RUnlock()
-	fmt.Println("Released read lock in GetPlayerList")
-
-	playerList := make([]string, 0, len(playersCopy))
-	for playerID := range playersCopy {
-		playerList = append(playerList, playerID)
-		fmt.Printf("Added to list: %s\n", playerID)
-	}
-	fmt.Printf("GetPlayerList returning: %v\n", playerList)
-	return playerList
-}
-
-func (pc *PlayerConnections) broadcastPlayerList() {
-	fmt.Println("Starting to broadcast player list")
-	time.Sleep(100 * time.Millisecond)  // Add a small delay
-	playerList := pc.GetPlayerList()
-	fmt.Printf("Player list: %v\n", playerList)
-	
-	message := map[string]interface{}{
-		"type":    "playerList",
-		"players": playerList,
-	}
-	
-	fmt.Printf("Attempting to marshal message: %+v\n", message)
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		fmt.Printf("Error marshaling player list: %v\n", err)
-		return
-	}
-	fmt.Println("JSON message created successfully")
-	
-	fmt.Printf("Attempting to broadcast message: %s\n", string(jsonMessage))
-	err = pc.BroadcastMessage("playerList", playerList)
-	if err != nil {
-		fmt.Printf("Error broadcasting message: %v\n", err)
-		return
-	}
-	
-	fmt.Println("Player list broadcasted successfully")
-}
-
-func (pc *PlayerConnections) GetPlayerConnection(playerID string) (*websocket.Conn, bool) {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
-	conn, exists := pc.players[playerID]
-	fmt.Printf("%s Exists? %t \n", playerID, exists);
-	return conn, exists
-}
-
-// BroadcastMessage sends a message to all connected players
-func (pc *PlayerConnections) BroadcastMessage(messageType string, message interface{}) error {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
-
-	// Create the JSON object
-	jsonMessage := struct {
-		Type    string      `json:"type"`
-		Data interface{} `json:"data"`
-	}{
-		Type:    messageType,
-		Data: message,
-	}
-
-	// Marshal the JSON object
-	jsonData, err := json.Marshal(jsonMessage)
-	if err != nil {
-		return fmt.Errorf("error marshaling message: %v", err)
-	}
-
-	for playerID, conn := range pc.players {
-		err := conn.WriteMessage(websocket.TextMessage, jsonData)
-		if err != nil {
-			fmt.Printf("Error broadcasting message to player %s: %v\n", playerID, err)
-			return err
-		}
-	}
-	return nil
+    return 0
 }
 
 // ShuffleAndDealCards shuffles the deck and deals cards to players
 func (g *Game) ShuffleAndDealCards() {
-	// Create a deck of 52 cards
-	deck := createDeck()
-
-	// Shuffle the deck
-	rand.Shuffle(len(deck), func(i, j int) {
-		deck[i], deck[j] = deck[j], deck[i]
-	})
-
-	// Deal cards to players
-	numPlayers := len(g.Players)
-	for i, card := range deck {
-		playerIndex := i % numPlayers
-		//playerID := g.Players[playerIndex]
-		
-		// Find the player in the GameState and add the card to their hand
-		switch playerIndex {
-		case 0:
-			g.State.Player1.Hand = append(g.State.Player1.Hand, card)
-		case 1:
-			g.State.Player2.Hand = append(g.State.Player2.Hand, card)
-		case 2:
-			g.State.Player3.Hand = append(g.State.Player3.Hand, card)
-		case 3:
-			g.State.Player4.Hand = append(g.State.Player4.Hand, card)
-		}
-	}
-}
+	hands := DealHands(len(g.State.Seats))
 
-// createDeck generates a standard 52-card deck
-func createDeck() []Card {
-	deck := make([]Card, 0, 52)
-	suits := []Suit{Hearts, Diamonds, Clubs, Spades}
-	ranks := []Rank{Ace, Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King}
-
-	for _, suit := range suits {
-		for _, rank := range ranks {
-			deck = append(deck, Card{Rank: rank, Suit: suit})
-		}
+	// Add the cards to each seat's hand
+	for i, player := range g.State.Players() {
+		player.Hand = append(player.Hand, hands[i]...)
 	}
+}
 
-	return deck
+// DealHands shuffles a fresh standard deck and deals it round-robin into
+// numPlayers equal hands
+func DealHands(numPlayers int) [][]Card {
+	return Dealing{}.Hands(numPlayers, NewSeed())
 }
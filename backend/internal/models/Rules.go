@@ -0,0 +1,54 @@
+package models
+
+// RuleSet configures which hands may be thrown in, which bids are allowed and
+// how strictly card play is checked
+type RuleSet struct {
+	Variant        string   `json:"variant"`
+	MustFollowSuit bool     `json:"must_follow_suit"`   // Follow the led suit when holding it
+	MustBeat       bool     `json:"must_beat"`          // Play higher than the winning card when able
+	MustTrump      bool     `json:"must_trump"`         // Trump with a spade when void in the led suit
+	MinBid         int      `json:"min_bid"`            // Lowest bid allowed, 1 when zero
+	MaxBid         int      `json:"max_bid"`            // Highest bid allowed, the number of tricks when zero
+	DealerHook     bool     `json:"dealer_hook"`        // The dealer, bidding last, may not make the bids add up to the number of tricks
+	Misdeals       []string `json:"misdeals,omitempty"` // Misdeal conditions under which a hand may be thrown in before bidding
+	AutoRedeal     bool     `json:"auto_redeal"`        // Throw such hands in right after the deal instead of waiting to be asked
+}
+
+// Misdeal conditions known to the rules engine
+const (
+	MisdealNoSpades = "nospades" // Not a single trump
+	MisdealNoFaces  = "nofaces"  // No jack, queen or king
+)
+
+// StrictCallBreak is the standard table rule set and the default for new games
+var StrictCallBreak = RuleSet{
+	Variant:        "strict",
+	MustFollowSuit: true,
+	MustBeat:       true,
+	MustTrump:      true,
+	Misdeals:       []string{MisdealNoSpades, MisdealNoFaces},
+}
+
+// HookCallBreak is strict Call Break where the bids may never add up to the
+// number of tricks, so somebody always misses
+var HookCallBreak = RuleSet{
+	Variant:        "hook",
+	MustFollowSuit: true,
+	MustBeat:       true,
+	MustTrump:      true,
+	DealerHook:     true,
+	Misdeals:       []string{MisdealNoSpades, MisdealNoFaces},
+}
+
+// CasualCallBreak only asks players to follow suit
+var CasualCallBreak = RuleSet{
+	Variant:        "casual",
+	MustFollowSuit: true,
+}
+
+// RuleVariants lists the rule sets by variant name
+var RuleVariants = map[string]RuleSet{
+	StrictCallBreak.Variant: StrictCallBreak,
+	HookCallBreak.Variant:   HookCallBreak,
+	CasualCallBreak.Variant: CasualCallBreak,
+}
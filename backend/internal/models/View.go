@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GameView is the snapshot of a Game as seen from a single seat. It keeps the
+// wire shape of Game so the frontend can consume it unchanged, but only the
+// viewer's own hand is included; opponents are reduced to a card count.
+type GameView struct {
+	GameID  string
+	Players []string
+	State   GameStateView
+	Viewer  string `json:"viewer"`
+}
+
+// GameStateView is the redacted GameState. Seats are sent as the "seats"
+// array and, until the frontend has migrated, also as player1..playerN keys.
+type GameStateView struct {
+	Seats       []PlayerView       `json:"seats"`
+	Turn        int                `json:"turn"`
+	Dealer      int                `json:"dealer"`
+	BidOrder    []string           `json:"bid_order"`
+	Bidder      string             `json:"bidder,omitempty"` // Player whose turn it is to bid, while bidding
+	TrickSuit   Suit               `json:"trick_suit"`
+	RoundWinner *PlayerView        `json:"round_winner,omitempty"`
+	Scores      map[string]int     `json:"scores"`
+	Bids        map[string]int     `json:"bids"`
+	Phase       Phase              `json:"phase"`
+	LastPlay    *PlayedCardMessage `json:"last_play,omitempty"`
+	Deal        int                `json:"deal"`
+	NumDeals    int                `json:"num_deals"`
+	Totals      map[string]float64 `json:"totals"`
+	History     []DealResult       `json:"history"`
+	Rules       RuleSet            `json:"rules"`
+}
+
+// MarshalJSON adds the legacy player1..playerN keys next to the seats array
+func (v GameStateView) MarshalJSON() ([]byte, error) {
+	type plain GameStateView
+	data, err := json.Marshal(plain(v))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for i, seat := range v.Seats {
+		raw, err := json.Marshal(seat)
+		if err != nil {
+			return nil, err
+		}
+		fields[fmt.Sprintf("player%d", i+1)] = raw
+	}
+	return json.Marshal(fields)
+}
+
+// PlayerView is the public part of a Player, plus the hand when the viewer
+// owns the seat. Hand is always an empty list for opponents so clients can
+// iterate it without special-casing.
+type PlayerView struct {
+	ID         string `json:"id"`
+	Hand       []Card `json:"hand"`
+	HandCount  int    `json:"hand_count"`
+	Health     int    `json:"health"`
+	PlayedCard *Card  `json:"played_card"`
+	Bid        int    `json:"bid"`
+	Score      int    `json:"score"`
+}
+
+// ViewFor builds the redacted snapshot of the game for viewerID. Played cards,
+// bids and scores are public; hands are only visible to their owner.
+func (g *Game) ViewFor(viewerID string) GameView {
+	return GameView{
+		GameID:  g.GameID,
+		Players: append([]string(nil), g.Players...),
+		State:   g.State.ViewFor(viewerID),
+		Viewer:  viewerID,
+	}
+}
+
+// ViewFor builds the redacted game state for viewerID.
+func (gs *GameState) ViewFor(viewerID string) GameStateView {
+	view := GameStateView{
+		Seats:     make([]PlayerView, len(gs.Seats)),
+		Turn:      gs.Turn,
+		Dealer:    gs.DealerPosition(),
+		BidOrder:  gs.BidOrder(),
+		TrickSuit: gs.TrickSuit,
+		Scores:    copyIntMap(gs.Scores),
+		Bids:      copyIntMap(gs.Bids),
+		Phase:     gs.Phase,
+		Deal:      gs.Deal,
+		NumDeals:  gs.NumDeals,
+		Totals:    copyFloatMap(gs.Totals),
+		History:   append([]DealResult(nil), gs.History...),
+		Rules:     gs.Rules,
+	}
+	for i := range gs.Seats {
+		view.Seats[i] = gs.Seats[i].ViewFor(viewerID)
+	}
+	if gs.Phase == PhaseBidding && gs.Turn >= 1 && gs.Turn <= len(gs.Seats) {
+		view.Bidder = gs.Seats[gs.Turn-1].ID
+	}
+	if gs.LastPlay != nil {
+		lastPlay := *gs.LastPlay
+		view.LastPlay = &lastPlay
+	}
+	if gs.RoundWinner != nil {
+		winner := gs.RoundWinner.ViewFor(viewerID)
+		view.RoundWinner = &winner
+	}
+	return view
+}
+
+// ViewFor returns the player as seen by viewerID.
+func (p *Player) ViewFor(viewerID string) PlayerView {
+	view := PlayerView{
+		ID:        p.ID,
+		Hand:      []Card{},
+		HandCount: len(p.Hand),
+		Health:    p.Health,
+		Bid:       p.Bid,
+		Score:     p.Score,
+	}
+	if p.PlayedCard != nil {
+		card := *p.PlayedCard
+		view.PlayedCard = &card
+	}
+	if p.ID != "" && p.ID == viewerID {
+		view.Hand = append(view.Hand, p.Hand...)
+	}
+	return view
+}
+
+func copyIntMap(m map[string]int) map[string]int {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]int, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func copyFloatMap(m map[string]float64) map[string]float64 {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]float64, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
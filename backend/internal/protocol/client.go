@@ -0,0 +1,136 @@
+package protocol
+
+import "dealer-backend/internal/models"
+
+// ClientMessage is a message sent by a client. Decode returns a pointer to
+// one of the structs below.
+type ClientMessage interface {
+	MessageType() string
+	Request() string
+}
+
+// Command holds what every client message but Hello carries besides its
+// payload. RequestID is chosen by the client and echoed in the reply.
+type Command struct {
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Request returns the ID the client gave the message, if any
+func (c *Command) Request() string { return c.RequestID }
+
+// Client message types
+const (
+	TypeHello          = "hello"
+	TypePlaceBid       = "placebid"
+	TypePlayCard       = "playcard"
+	TypeAcknowledgment = "acknowledgment"
+	TypeBack           = "back"
+	TypeRequestRedeal  = "requestredeal"
+	TypeEnqueue        = "enqueue"
+	TypeDequeue        = "dequeue"
+	TypeCreateRoom     = "createroom"
+	TypeJoinRoom       = "joinroom"
+	TypeConfigureRoom  = "configureroom"
+	TypeLeaveRoom      = "leaveroom"
+	TypeStartRoom      = "startroom"
+)
+
+// Hello opens a connection. Its type field is optional, older clients send
+// just the token.
+type Hello struct {
+	Token   string `json:"token"`
+	Version int    `json:"version,omitempty"` // Highest version the client speaks, 1 when absent
+}
+
+// PlaceBid bids for the sender during bidding
+type PlaceBid struct {
+	Command
+	Bid int `json:"bid"`
+}
+
+// PlayCard puts a card from the sender's hand on the table
+type PlayCard struct {
+	Command
+	Card models.Card `json:"card"`
+}
+
+// Acknowledgment confirms the last broadcast has been processed, which lets
+// the turn timers run again
+type Acknowledgment struct{ Command }
+
+// Back tells the room a player marked away is playing again
+type Back struct{ Command }
+
+// RequestRedeal asks for the hands to be thrown in because the sender's hand
+// is misdealt
+type RequestRedeal struct{ Command }
+
+// Enqueue asks the matchmaker for a game, from the lobby
+type Enqueue struct{ Command }
+
+// Dequeue stops looking for a game
+type Dequeue struct{ Command }
+
+// RoomSettings configure the game of a private room. Zero values keep the
+// defaults of matchmade games.
+type RoomSettings struct {
+	TurnSeconds int    `json:"turnSeconds,omitempty"` // Time a player has to play a card
+	NumDeals    int    `json:"numDeals,omitempty"`    // Deals in the match
+	Variant     string `json:"variant,omitempty"`     // Rule variant by name, strict when empty
+	Bots        bool   `json:"bots"`                  // Bots take the seats still empty at the start
+}
+
+// CreateRoom opens a private room hosted by the sender, from the lobby
+type CreateRoom struct {
+	Command
+	Settings RoomSettings `json:"settings"`
+}
+
+// JoinRoom enters the private room with the given join code
+type JoinRoom struct {
+	Command
+	Code string `json:"code"`
+}
+
+// ConfigureRoom changes the settings of the sender's private room. Only the
+// host may.
+type ConfigureRoom struct {
+	Command
+	Settings RoomSettings `json:"settings"`
+}
+
+// LeaveRoom leaves the sender's private room
+type LeaveRoom struct{ Command }
+
+// StartRoom starts the game of the sender's private room. Only the host may.
+type StartRoom struct{ Command }
+
+func (*Hello) MessageType() string          { return TypeHello }
+func (*PlaceBid) MessageType() string       { return TypePlaceBid }
+func (*PlayCard) MessageType() string       { return TypePlayCard }
+func (*Acknowledgment) MessageType() string { return TypeAcknowledgment }
+func (*Back) MessageType() string           { return TypeBack }
+func (*RequestRedeal) MessageType() string  { return TypeRequestRedeal }
+func (*Enqueue) MessageType() string        { return TypeEnqueue }
+func (*Dequeue) MessageType() string        { return TypeDequeue }
+func (*CreateRoom) MessageType() string     { return TypeCreateRoom }
+func (*JoinRoom) MessageType() string       { return TypeJoinRoom }
+func (*ConfigureRoom) MessageType() string  { return TypeConfigureRoom }
+func (*LeaveRoom) MessageType() string      { return TypeLeaveRoom }
+func (*StartRoom) MessageType() string      { return TypeStartRoom }
+
+// clientMessages are the messages Decode accepts once connected, by type
+var clientMessages = map[string]func() ClientMessage{
+	TypePlaceBid:       func() ClientMessage { return &PlaceBid{} },
+	TypePlayCard:       func() ClientMessage { return &PlayCard{} },
+	TypeAcknowledgment: func() ClientMessage { return &Acknowledgment{} },
+	TypeBack:           func() ClientMessage { return &Back{} },
+	TypeRequestRedeal:  func() ClientMessage { return &RequestRedeal{} },
+	TypeEnqueue:        func() ClientMessage { return &Enqueue{} },
+	TypeDequeue:        func() ClientMessage { return &Dequeue{} },
+	TypeCreateRoom:     func() ClientMessage { return &CreateRoom{} },
+	TypeJoinRoom:       func() ClientMessage { return &JoinRoom{} },
+	TypeConfigureRoom:  func() ClientMessage { return &ConfigureRoom{} },
+	TypeLeaveRoom:      func() ClientMessage { return &LeaveRoom{} },
+	TypeStartRoom:      func() ClientMessage { return &StartRoom{} },
+}
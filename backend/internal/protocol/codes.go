@@ -0,0 +1,118 @@
+package protocol
+
+import (
+	"errors"
+
+	"dealer-backend/internal/engine"
+)
+
+// Error codes sent in Error messages. They are stable: a code is never
+// renamed or reused, so clients can key their own wording on it.
+const (
+	CodeMalformed          = "malformed"           // Not JSON, or fields of the wrong type
+	CodeUnknownType        = "unknown_type"        // No client message has this type
+	CodeUnsupportedVersion = "unsupported_version" // Negotiate refused the client's version
+	CodeUnauthorized       = "unauthorized"        // The Hello token is missing or invalid
+	CodeNotSeated          = "not_seated"
+	CodeWrongPhase         = "wrong_phase"
+	CodeNotYourTurn        = "not_your_turn"
+	CodeAlreadyBid         = "already_bid"
+	CodeBidTooLow          = "bid_too_low"
+	CodeBidTooHigh         = "bid_too_high"
+	CodeBidMakesTotal      = "bid_makes_total"
+	CodeBiddingStarted     = "bidding_started"
+	CodeNoMisdeal          = "no_misdeal"
+	CodeAlreadyPlayed      = "already_played"
+	CodeCardNotInHand      = "card_not_in_hand"
+	CodeMustFollowSuit     = "must_follow_suit"
+	CodeMustBeat           = "must_beat"
+	CodeMustTrump          = "must_trump"
+	CodeRoomClosed         = "room_closed"
+	CodeSeatForfeited      = "seat_forfeited"
+	CodeAlreadyPlaying     = "already_playing"
+	CodeNotQueued          = "not_queued"
+	CodeNotInLobby         = "not_in_lobby"
+	CodeMatchmakerStopped  = "matchmaker_stopped"
+	CodeRoomNotFound       = "room_not_found"
+	CodeRoomFull           = "room_full"
+	CodeNotInRoom          = "not_in_room"
+	CodeInPrivateRoom      = "in_private_room"
+	CodeNotHost            = "not_host"
+	CodeInvalidSettings    = "invalid_settings"
+	CodeTooFewPlayers      = "too_few_players"
+	CodeRoomChanged        = "room_changed"
+	CodeInternal           = "internal" // Anything else; the message has the details
+)
+
+// ErrorCode documents one of the codes
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	err         error  // Engine error reported with this code, if any
+}
+
+// ErrorCodes lists every code a client may receive. It is exported with the
+// schema so the frontend can check it handles them all.
+var ErrorCodes = []ErrorCode{
+	{CodeMalformed, "The message is not JSON or a field has the wrong type.", nil},
+	{CodeUnknownType, "No message has this type, or it is not accepted here.", nil},
+	{CodeUnsupportedVersion, "The client's protocol version is too old.", nil},
+	{CodeUnauthorized, "The token is missing, invalid or expired.", nil},
+	{CodeNotSeated, "The player has no seat in this game.", engine.ErrUnknownPlayer},
+	{CodeWrongPhase, "The game is not in a phase that allows this.", engine.ErrWrongPhase},
+	{CodeNotYourTurn, "Another player is on turn.", engine.ErrNotYourTurn},
+	{CodeAlreadyBid, "The player has already bid this deal.", engine.ErrAlreadyBid},
+	{CodeBidTooLow, "The bid is below the table's minimum.", engine.ErrBidTooLow},
+	{CodeBidTooHigh, "The bid is above the table's maximum.", engine.ErrBidTooHigh},
+	{CodeBidMakesTotal, "The dealer may not make the bids add up to the number of tricks.", engine.ErrBidMakesTotal},
+	{CodeBiddingStarted, "Bidding has started, the hands can no longer be thrown in.", engine.ErrBiddingStarted},
+	{CodeNoMisdeal, "The hand does not meet any misdeal condition of the table.", engine.ErrNoMisdeal},
+	{CodeAlreadyPlayed, "The player already has a card on the table.", engine.ErrAlreadyPlayed},
+	{CodeCardNotInHand, "The card is not in the player's hand.", engine.ErrCardNotInHand},
+	{CodeMustFollowSuit, "The player holds the suit led and must play it.", engine.ErrMustFollowSuit},
+	{CodeMustBeat, "The player holds a card that beats the trick and must play it.", engine.ErrMustBeat},
+	{CodeMustTrump, "The player is out of the suit led and must play a spade.", engine.ErrMustTrump},
+	{CodeRoomClosed, "The game has finished, its room takes no more commands.", nil},
+	{CodeSeatForfeited, "The player was away longer than the grace period and lost their seat.", nil},
+	{CodeAlreadyPlaying, "The player already has a seat in a game.", nil},
+	{CodeNotQueued, "The player is not waiting for a game.", nil},
+	{CodeNotInLobby, "The player is not connected to the lobby.", nil},
+	{CodeMatchmakerStopped, "The server is not matching players at the moment.", nil},
+	{CodeRoomNotFound, "No private room has this join code.", nil},
+	{CodeRoomFull, "Every seat of the private room is taken.", nil},
+	{CodeNotInRoom, "The player is not in a private room.", nil},
+	{CodeInPrivateRoom, "The player is in a private room and must leave it first.", nil},
+	{CodeNotHost, "Only the host of the private room may do this.", nil},
+	{CodeInvalidSettings, "A room setting is out of range or unknown.", nil},
+	{CodeTooFewPlayers, "Too few players to start the game without bots.", nil},
+	{CodeRoomChanged, "A player left the private room while it was starting.", nil},
+	{CodeInternal, "The server could not carry out the command.", nil},
+}
+
+// codedError is an error that knows its code
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e *codedError) Error() string { return e.message }
+
+// NewError returns an error reported with code, for errors defined outside
+// the engine
+func NewError(code, message string) error {
+	return &codedError{code: code, message: message}
+}
+
+// CodeFor returns the code reported for err
+func CodeFor(err error) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	for _, code := range ErrorCodes {
+		if code.err != nil && errors.Is(err, code.err) {
+			return code.Code
+		}
+	}
+	return CodeInternal
+}
@@ -0,0 +1,133 @@
+// Package protocol defines the messages exchanged over the game WebSocket.
+//
+// A connection starts with the client's Hello, answered by a Welcome naming
+// the protocol version both sides speak. After that every server message is
+// an Envelope carrying its type, the version and a sequence number, with the
+// payload under data. Client messages are flat JSON objects with a type
+// field and an optional requestId, e.g. {"type":"placebid","requestId":"7",
+// "bid":3}, and are each answered by an Ok or an Error carrying that ID.
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Protocol versions the server speaks
+const (
+	Version    = 1 // Latest, used for every message the server writes
+	MinVersion = 1 // Oldest still accepted on connect
+)
+
+// ErrUnsupportedVersion is returned by Negotiate for clients that are too old
+var ErrUnsupportedVersion = errors.New("unsupported protocol version")
+
+// Negotiate picks the version spoken with a client that asked for requested.
+// Clients that predate versioning send none and speak version 1; clients
+// newer than the server are answered in the server's latest version.
+func Negotiate(requested int) (int, error) {
+	switch {
+	case requested == 0:
+		return MinVersion, nil
+	case requested < MinVersion:
+		return 0, fmt.Errorf("%w: %d, need at least %d", ErrUnsupportedVersion, requested, MinVersion)
+	case requested > Version:
+		return Version, nil
+	default:
+		return requested, nil
+	}
+}
+
+// Envelope wraps every server message. Seq numbers the messages sent to one
+// player, from 1, separately in the lobby and in each game, so a client can
+// tell when it missed one. Welcome and handshake errors carry seq 0.
+type Envelope struct {
+	Type    string      `json:"type"`
+	Version int         `json:"v"`
+	Seq     uint64      `json:"seq"`
+	Data    interface{} `json:"data"`
+}
+
+// Encode wraps msg in its envelope and marshals it
+func Encode(seq uint64, msg Message) ([]byte, error) {
+	return json.Marshal(Envelope{
+		Type:    msg.MessageType(),
+		Version: Version,
+		Seq:     seq,
+		Data:    msg,
+	})
+}
+
+// DecodeError is returned by Decode and DecodeHello for messages the server
+// can't act on. ErrorReply turns it into the message sent back to the client.
+type DecodeError struct {
+	Code      string
+	Type      string // Type of the offending message, when it could be read
+	RequestID string // Request ID of the offending message, when it could be read
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return fmt.Sprintf("%s %q: %v", e.Code, e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// ErrorReply is the Error message telling the client why its message was
+// refused by Decode or DecodeHello, or why the game refused the command
+func ErrorReply(err error) Error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return Error{Code: decodeErr.Code, Message: decodeErr.Err.Error(), Type: decodeErr.Type, RequestID: decodeErr.RequestID}
+	}
+	return Error{Code: CodeFor(err), Message: err.Error()}
+}
+
+// Reply answers msg: Ok when err is nil, an Error otherwise. Both carry the
+// message's request ID so the client can match them to what it sent.
+func Reply(msg ClientMessage, err error) Message {
+	if err == nil {
+		return Ok{RequestID: msg.Request(), Type: msg.MessageType()}
+	}
+	reply := ErrorReply(err)
+	reply.RequestID = msg.Request()
+	reply.Type = msg.MessageType()
+	return reply
+}
+
+// Decode parses a client message into its typed struct
+func Decode(raw []byte) (ClientMessage, error) {
+	var head struct {
+		Type      string `json:"type"`
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, &DecodeError{Code: CodeMalformed, Err: err}
+	}
+
+	newMessage, known := clientMessages[head.Type]
+	if !known {
+		return nil, &DecodeError{Code: CodeUnknownType, Type: head.Type, RequestID: head.RequestID, Err: fmt.Errorf("unknown message type %q", head.Type)}
+	}
+	msg := newMessage()
+	if err := json.Unmarshal(raw, msg); err != nil {
+		return nil, &DecodeError{Code: CodeMalformed, Type: head.Type, RequestID: head.RequestID, Err: err}
+	}
+	return msg, nil
+}
+
+// DecodeHello parses the first message of a connection
+func DecodeHello(raw []byte) (Hello, error) {
+	var hello Hello
+	if err := json.Unmarshal(raw, &hello); err != nil {
+		return hello, &DecodeError{Code: CodeMalformed, Type: TypeHello, Err: err}
+	}
+	if hello.Token == "" {
+		return hello, &DecodeError{Code: CodeUnauthorized, Type: TypeHello, Err: errors.New("hello has no token")}
+	}
+	return hello, nil
+}
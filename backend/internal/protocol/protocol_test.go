@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"dealer-backend/internal/engine"
+)
+
+func TestDecodeReturnsTypedMessages(t *testing.T) {
+	msg, err := Decode([]byte(`{"type":"placebid","bid":3}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	bid, ok := msg.(*PlaceBid)
+	if !ok {
+		t.Fatalf("decoded %T, want *PlaceBid", msg)
+	}
+	if bid.Bid != 3 {
+		t.Errorf("bid = %d, want 3", bid.Bid)
+	}
+}
+
+func TestDecodeRefusesBadMessages(t *testing.T) {
+	tests := []struct {
+		raw  string
+		code string
+		typ  string
+	}{
+		{`not json`, CodeMalformed, ""},
+		{`{"type":"placebid","bid":"three"}`, CodeMalformed, TypePlaceBid},
+		{`{"type":"cheat"}`, CodeUnknownType, "cheat"},
+		{`{"bid":3}`, CodeUnknownType, ""},
+	}
+	for _, tt := range tests {
+		_, err := Decode([]byte(tt.raw))
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("Decode(%s) = %v, want a DecodeError", tt.raw, err)
+			continue
+		}
+		reply := ErrorReply(err)
+		if reply.Code != tt.code || reply.Type != tt.typ {
+			t.Errorf("Decode(%s) replies %+v, want code %q type %q", tt.raw, reply, tt.code, tt.typ)
+		}
+	}
+}
+
+func TestRepliesCarryTheRequestID(t *testing.T) {
+	msg, err := Decode([]byte(`{"type":"placebid","requestId":"42","bid":1}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if ok, _ := Reply(msg, nil).(Ok); ok.RequestID != "42" || ok.Type != TypePlaceBid {
+		t.Errorf("ok reply = %+v", Reply(msg, nil))
+	}
+
+	reply, _ := Reply(msg, fmt.Errorf("bid 1: %w", engine.ErrNotYourTurn)).(Error)
+	if reply.RequestID != "42" || reply.Code != CodeNotYourTurn {
+		t.Errorf("error reply = %+v", reply)
+	}
+
+	_, err = Decode([]byte(`{"type":"placebid","requestId":"43","bid":true}`))
+	if reply := ErrorReply(err); reply.RequestID != "43" || reply.Code != CodeMalformed {
+		t.Errorf("malformed reply = %+v", reply)
+	}
+}
+
+func TestErrorCodesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, code := range ErrorCodes {
+		if seen[code.Code] {
+			t.Errorf("code %q listed twice", code.Code)
+		}
+		seen[code.Code] = true
+		if code.err != nil && CodeFor(code.err) != code.Code {
+			t.Errorf("CodeFor(%v) = %q, want %q", code.err, CodeFor(code.err), code.Code)
+		}
+	}
+	if got := CodeFor(errors.New("disk on fire")); got != CodeInternal {
+		t.Errorf("CodeFor(unknown) = %q, want %q", got, CodeInternal)
+	}
+}
+
+func TestDecodeHelloNeedsAToken(t *testing.T) {
+	hello, err := DecodeHello([]byte(`{"token":"abc"}`))
+	if err != nil || hello.Token != "abc" || hello.Version != 0 {
+		t.Errorf("legacy hello = %+v, %v", hello, err)
+	}
+	if _, err := DecodeHello([]byte(`{"type":"hello","version":1}`)); ErrorReply(err).Code != CodeUnauthorized {
+		t.Errorf("hello without token: %v, want %s", err, CodeUnauthorized)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		requested, want int
+	}{
+		{0, MinVersion},
+		{Version, Version},
+		{Version + 1, Version},
+	}
+	for _, tt := range tests {
+		if got, err := Negotiate(tt.requested); err != nil || got != tt.want {
+			t.Errorf("Negotiate(%d) = %d, %v, want %d", tt.requested, got, err, tt.want)
+		}
+	}
+	if _, err := Negotiate(-1); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Negotiate(-1) = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestEncodeWrapsMessagesInAnEnvelope(t *testing.T) {
+	raw, err := Encode(7, Error{Code: CodeBidTooHigh, Message: "too high"})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var envelope struct {
+		Type    string
+		Version int `json:"v"`
+		Seq     uint64
+		Data    Error
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		t.Fatalf("unmarshal %s: %v", raw, err)
+	}
+	if envelope.Type != "error" || envelope.Version != Version || envelope.Seq != 7 || envelope.Data.Code != CodeBidTooHigh {
+		t.Errorf("envelope = %s", raw)
+	}
+}
+
+// The frontend validates against the exported schema, so it must be
+// regenerated whenever a message changes
+func TestSchemaIsUpToDate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf); err != nil {
+		t.Fatalf("write schema: %v", err)
+	}
+	exported, err := os.ReadFile("../../../frontend/src/protocol/schema.json")
+	if err != nil {
+		t.Skipf("no exported schema: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), exported) {
+		t.Errorf("frontend/src/protocol/schema.json is stale, run go generate ./internal/protocol")
+	}
+}
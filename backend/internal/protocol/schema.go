@@ -0,0 +1,166 @@
+package protocol
+
+//go:generate go run ../../cmd/schema -out ../../../frontend/src/protocol/schema.json
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema describes every message of the protocol as a JSON Schema (draft
+// 2020-12), built from the Go structs so it can't drift from them. A document
+// validates when it is a server envelope, a client message or a Hello.
+func Schema() map[string]interface{} {
+	b := &schemaBuilder{defs: make(map[string]interface{})}
+
+	var server []interface{}
+	for _, msg := range serverMessages {
+		name := "server." + msg.MessageType()
+		b.defs[name] = map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"type": map[string]interface{}{"const": msg.MessageType()},
+				"v":    map[string]interface{}{"type": "integer", "minimum": MinVersion},
+				"seq":  map[string]interface{}{"type": "integer", "minimum": 0},
+				"data": b.of(reflect.TypeOf(msg)),
+			},
+			"required": []string{"type", "v", "seq", "data"},
+		}
+		server = append(server, ref(name))
+	}
+	b.defs["ServerMessage"] = map[string]interface{}{"oneOf": server}
+
+	types := make([]string, 0, len(clientMessages))
+	for messageType := range clientMessages {
+		types = append(types, messageType)
+	}
+	sort.Strings(types)
+	var client []interface{}
+	for _, messageType := range types {
+		name := "client." + messageType
+		def := b.object(reflect.TypeOf(clientMessages[messageType]()).Elem())
+		def["properties"].(map[string]interface{})["type"] = map[string]interface{}{"const": messageType}
+		def["required"] = append(def["required"].([]string), "type")
+		b.defs[name] = def
+		client = append(client, ref(name))
+	}
+	b.defs["ClientMessage"] = map[string]interface{}{"oneOf": client}
+
+	codes := make([]interface{}, len(ErrorCodes))
+	for i, code := range ErrorCodes {
+		codes[i] = map[string]interface{}{"const": code.Code, "description": code.Description}
+	}
+	b.defs["ErrorCode"] = map[string]interface{}{"oneOf": codes}
+	b.defs["Error"].(map[string]interface{})["properties"].(map[string]interface{})["code"] = ref("ErrorCode")
+
+	hello := b.object(reflect.TypeOf(Hello{}))
+	hello["properties"].(map[string]interface{})["type"] = map[string]interface{}{"const": TypeHello}
+	b.defs["client.hello"] = hello
+
+	return map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "Dealer WebSocket protocol",
+		"version": Version,
+		"$defs":   b.defs,
+		"oneOf":   []interface{}{ref("ServerMessage"), ref("ClientMessage"), ref("client.hello")},
+	}
+}
+
+// WriteSchema writes Schema as indented JSON
+func WriteSchema(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Schema())
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+type schemaBuilder struct {
+	defs map[string]interface{}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// of returns the schema of values of type t as encoding/json writes them
+func (b *schemaBuilder) of(t reflect.Type) map[string]interface{} {
+	if t == rawMessageType {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{"anyOf": []interface{}{b.of(t.Elem()), map[string]interface{}{"type": "null"}}}
+	case reflect.Struct:
+		// Named structs are described once under $defs
+		name := strings.TrimPrefix(t.String(), "protocol.")
+		if _, done := b.defs[name]; !done {
+			b.defs[name] = nil // Placeholder, in case the type refers to itself
+			b.defs[name] = b.object(t)
+		}
+		return ref(name)
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": b.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": b.of(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object describes the fields of struct type t. Fields without omitempty are
+// required; extra properties are allowed so clients may add their own.
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("json") == "" && field.Type.Kind() == reflect.Struct {
+			// Embedded structs are flattened by encoding/json
+			embedded := b.object(field.Type)
+			for name, property := range embedded["properties"].(map[string]interface{}) {
+				properties[name] = property
+			}
+			required = append(required, embedded["required"].([]string)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name, options := field.Name, ""
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if comma := strings.Index(tag, ","); comma >= 0 {
+				tag, options = tag[:comma], tag[comma:]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		properties[name] = b.of(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
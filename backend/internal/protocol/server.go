@@ -0,0 +1,226 @@
+package protocol
+
+import (
+	"encoding/json"
+
+	"dealer-backend/internal/models"
+)
+
+// Message is a message sent by the server, wrapped in an Envelope by Encode
+type Message interface {
+	MessageType() string
+}
+
+// Welcome answers a Hello once the player is authenticated
+type Welcome struct {
+	Version  int    `json:"version"` // Version spoken on this connection
+	PlayerID string `json:"playerId"`
+}
+
+// Ok tells a client its command was carried out
+type Ok struct {
+	RequestID string `json:"requestId,omitempty"`
+	Type      string `json:"type"` // Type of the command
+}
+
+// Error tells a client why its message was refused. Code is one of the
+// ErrorCodes, Message is for people and may change.
+type Error struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Type      string `json:"type,omitempty"`      // Type of the refused message
+	RequestID string `json:"requestId,omitempty"` // Request ID of the refused message
+}
+
+// Notice is a line of lobby news, such as a player joining
+type Notice string
+
+// PlayerList lists the players waiting in the lobby
+type PlayerList []string
+
+// Queued confirms a place in the matchmaking queue
+type Queued struct {
+	TicketID string `json:"ticketId"`
+	Position int    `json:"position"` // 1 for the longest waiting player
+}
+
+// MatchFound is the first message of a game, telling each player their seat
+type MatchFound struct {
+	RoomID  string   `json:"roomId"`
+	Seat    int      `json:"seat"` // From 1, in the order of Players
+	Players []string `json:"players"`
+}
+
+// PrivateRoom is the state of the receiver's private room, sent to everyone
+// in it whenever it changes
+type PrivateRoom struct {
+	Code     string       `json:"code"` // Join code to share
+	Host     string       `json:"host"` // The player who may configure and start the room
+	Players  []string     `json:"players"`
+	Seats    int          `json:"seats"` // Players the room holds
+	Settings RoomSettings `json:"settings"`
+}
+
+// GameState is the whole game as seen by the receiving seat
+type GameState models.GameView
+
+// HealthState is the turn timer of the player on turn, from 100 down to 0
+type HealthState struct {
+	Player string `json:"player"`
+	Health int    `json:"health"`
+}
+
+// DealStarted announces fresh hands and who dealt them
+type DealStarted struct {
+	Deal     int      `json:"deal"`
+	NumDeals int      `json:"numDeals"`
+	Dealer   string   `json:"dealer"`
+	BidOrder []string `json:"bidOrder"`
+}
+
+// RedealOffer tells a player their hand may be thrown in, if they ask within
+// the given number of seconds
+type RedealOffer struct {
+	Reason  string `json:"reason"`
+	Seconds int    `json:"seconds"`
+}
+
+// Misdeal announces that the hands were thrown in and will be dealt again
+type Misdeal struct {
+	PlayerID  string `json:"playerId"`
+	Reason    string `json:"reason"`
+	Requested bool   `json:"requested"` // The player asked for it
+}
+
+// UpdateBid reminds the player on turn to bid
+type UpdateBid struct {
+	Message string `json:"message"`
+}
+
+// BidUpdate is the bid of the player on turn
+type BidUpdate struct {
+	PlayerID string `json:"playerId"`
+	Bid      int    `json:"bid"`
+}
+
+// AutoBid announces the bid placed for a player whose timer ran out
+type AutoBid struct {
+	PlayerID string `json:"playerId"`
+	Bid      int    `json:"bid"`
+}
+
+// BiddingComplete announces that everyone has bid and play starts
+type BiddingComplete struct{}
+
+// CardPlayed announces a card put on the table
+type CardPlayed struct {
+	PlayerID string      `json:"playerId"`
+	Card     models.Card `json:"card"`
+}
+
+// AutoPlayed announces the card played for a player whose timer ran out
+type AutoPlayed struct {
+	PlayerID string      `json:"playerId"`
+	Card     models.Card `json:"card"`
+}
+
+// TrickWon announces the winner of a trick. Their hand is only included for
+// the winner themselves.
+type TrickWon struct {
+	Player *models.PlayerView `json:"player"`
+	Score  int                `json:"score"`
+}
+
+// ResetCardPlayed tells the clients to clear the table
+type ResetCardPlayed struct{}
+
+// DealOver is the result of a deal and the running totals
+type DealOver struct {
+	Deal     int                `json:"deal"`
+	NumDeals int                `json:"numDeals"`
+	Result   *models.DealResult `json:"result"`
+	Totals   map[string]float64 `json:"totals"`
+}
+
+// GameOver is the final standings of the match
+type GameOver struct {
+	PlayerID  string              `json:"playerId"`
+	Standings []models.Standing   `json:"standings"`
+	History   []models.DealResult `json:"history"`
+}
+
+// PlayerAway announces a player whose turns are played for them until they
+// come back
+type PlayerAway struct {
+	PlayerID string `json:"playerId"`
+}
+
+// PlayerBack announces a player who is playing again
+type PlayerBack struct {
+	PlayerID string `json:"playerId"`
+}
+
+// PlayerDisconnected announces a player whose connection was lost. Their
+// seat is held for the given number of seconds.
+type PlayerDisconnected struct {
+	PlayerID string `json:"playerId"`
+	Seconds  int    `json:"seconds"`
+}
+
+// PlayerReconnected announces a player whose connection is back
+type PlayerReconnected struct {
+	PlayerID string `json:"playerId"`
+}
+
+// SeatForfeited announces a player who did not reconnect in time
+type SeatForfeited struct {
+	PlayerID string `json:"playerId"`
+}
+
+// Resume brings a reconnecting player up to date: the game as it is now and
+// the envelopes sent while they were away, in order
+type Resume struct {
+	Snapshot models.GameView   `json:"snapshot"`
+	Missed   []json.RawMessage `json:"missed"`
+}
+
+func (Welcome) MessageType() string            { return "welcome" }
+func (Ok) MessageType() string                 { return "ok" }
+func (Error) MessageType() string              { return "error" }
+func (Notice) MessageType() string             { return "message" }
+func (PlayerList) MessageType() string         { return "playerList" }
+func (Queued) MessageType() string             { return "queued" }
+func (MatchFound) MessageType() string         { return "match_found" }
+func (PrivateRoom) MessageType() string        { return "private_room" }
+func (GameState) MessageType() string          { return "gamestate" }
+func (HealthState) MessageType() string        { return "healthstate" }
+func (DealStarted) MessageType() string        { return "dealstarted" }
+func (RedealOffer) MessageType() string        { return "redealoffer" }
+func (Misdeal) MessageType() string            { return "misdeal" }
+func (UpdateBid) MessageType() string          { return "updatebid" }
+func (BidUpdate) MessageType() string          { return "bidupdate" }
+func (AutoBid) MessageType() string            { return "autobid" }
+func (BiddingComplete) MessageType() string    { return "biddingcomplete" }
+func (CardPlayed) MessageType() string         { return "cardplayed" }
+func (AutoPlayed) MessageType() string         { return "autoplayed" }
+func (TrickWon) MessageType() string           { return "trickwon" }
+func (ResetCardPlayed) MessageType() string    { return "resetcardplayed" }
+func (DealOver) MessageType() string           { return "dealover" }
+func (GameOver) MessageType() string           { return "gameover" }
+func (PlayerAway) MessageType() string         { return "playeraway" }
+func (PlayerBack) MessageType() string         { return "playerback" }
+func (PlayerDisconnected) MessageType() string { return "player_disconnected" }
+func (PlayerReconnected) MessageType() string  { return "player_reconnected" }
+func (SeatForfeited) MessageType() string      { return "seatforfeited" }
+func (Resume) MessageType() string             { return "resume" }
+
+// serverMessages lists one of each server message, for the schema
+var serverMessages = []Message{
+	Welcome{}, Ok{}, Error{}, Notice(""), PlayerList(nil), Queued{}, MatchFound{}, PrivateRoom{},
+	GameState{}, HealthState{},
+	DealStarted{}, RedealOffer{}, Misdeal{},
+	UpdateBid{}, BidUpdate{}, AutoBid{}, BiddingComplete{},
+	CardPlayed{}, AutoPlayed{}, TrickWon{}, ResetCardPlayed{},
+	DealOver{}, GameOver{},
+	PlayerAway{}, PlayerBack{}, PlayerDisconnected{}, PlayerReconnected{}, SeatForfeited{}, Resume{},
+}
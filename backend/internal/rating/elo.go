@@ -0,0 +1,64 @@
+// Package rating rates players from where they finish in the games they
+// play. It uses Elo generalized to more than two players: a game counts as a
+// match between every pair of players at the table, won by whoever placed
+// higher, and the rating change is the average over those pairings.
+package rating
+
+import "math"
+
+const (
+	Initial = 1500.0 // Rating of a player who has not finished a game yet
+	K       = 32.0   // Most a rating can move in one game
+	scale   = 400.0  // Rating difference at which the stronger player is ten times as likely to win
+)
+
+// Result is how one player did in a game
+type Result struct {
+	Rating float64 // Rating before the game
+	Place  int     // 1 for the winner; tied players share a place
+}
+
+// Expected returns the score, between 0 and 1, a player rated rating is
+// expected to take off an opponent rated opponent: 1 for placing above them,
+// 0 for below and a half for a tie
+func Expected(rating, opponent float64) float64 {
+	return 1 / (1 + math.Pow(10, (opponent-rating)/scale))
+}
+
+// Update returns the ratings after the game, in the order of results. What
+// one player gains the others lose, so the ratings at a table add up to the
+// same total before and after.
+func Update(results []Result) []float64 {
+	ratings := make([]float64, len(results))
+	for i, result := range results {
+		ratings[i] = result.Rating
+	}
+	if len(results) < 2 {
+		return ratings
+	}
+
+	weight := K / float64(len(results)-1)
+	for i, player := range results {
+		var delta float64
+		for j, opponent := range results {
+			if i == j {
+				continue
+			}
+			delta += score(player.Place, opponent.Place) - Expected(player.Rating, opponent.Rating)
+		}
+		ratings[i] += weight * delta
+	}
+	return ratings
+}
+
+// score is what a pairing is worth to the player placed place
+func score(place, opponent int) float64 {
+	switch {
+	case place < opponent:
+		return 1
+	case place == opponent:
+		return 0.5
+	default:
+		return 0
+	}
+}
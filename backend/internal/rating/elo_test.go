@@ -0,0 +1,59 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqualPlayersMoveByPlace(t *testing.T) {
+	got := Update([]Result{
+		{Rating: Initial, Place: 1},
+		{Rating: Initial, Place: 2},
+		{Rating: Initial, Place: 3},
+		{Rating: Initial, Place: 4},
+	})
+
+	// Against equals each pairing is worth ±K/2, averaged over three opponents
+	want := []float64{Initial + 16, Initial + 16.0/3, Initial - 16.0/3, Initial - 16}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("place %d: rating %.3f, want %.3f", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestRatingsAreConserved(t *testing.T) {
+	results := []Result{
+		{Rating: 1820, Place: 3},
+		{Rating: 1400, Place: 1},
+		{Rating: 1510, Place: 1},
+		{Rating: 1650, Place: 4},
+	}
+	var before, after float64
+	for i, rating := range Update(results) {
+		before += results[i].Rating
+		after += rating
+	}
+	if math.Abs(before-after) > 1e-9 {
+		t.Errorf("ratings add up to %.3f after the game, %.3f before", after, before)
+	}
+}
+
+func TestUpsetsMoveRatingsMore(t *testing.T) {
+	expected := Update([]Result{{Rating: 1800, Place: 1}, {Rating: 1400, Place: 2}})
+	upset := Update([]Result{{Rating: 1800, Place: 2}, {Rating: 1400, Place: 1}})
+
+	gained, lost := expected[0]-1800, 1800-upset[0]
+	if gained <= 0 || lost <= gained {
+		t.Errorf("favourite gains %.2f for winning and loses %.2f for losing, want a small gain and a larger loss", gained, lost)
+	}
+}
+
+func TestTiesBetweenEqualsChangeNothing(t *testing.T) {
+	got := Update([]Result{{Rating: 1600, Place: 1}, {Rating: 1600, Place: 1}, {Rating: 1600, Place: 1}})
+	for i, rating := range got {
+		if rating != 1600 {
+			t.Errorf("player %d: rating %.3f after a three way tie, want 1600", i, rating)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+	"fmt"
+	"time"
+)
+
+// newBots creates bots at level for seats from to to-1 of gameID
+func newBots(gameID string, from, to int, level bot.Level) []*bot.Bot {
+	bots := make([]*bot.Bot, 0, to-from)
+	for seat := from; seat < to; seat++ {
+		playerID := fmt.Sprintf("bot-%s-%d", gameID, seat)
+		bots = append(bots, bot.New(playerID, level, newBotSeed()))
+	}
+	return bots
+}
+
+// newBotSeed picks an unpredictable seed for a bot's choices
+func newBotSeed() int64 {
+	return int64(models.CryptoSource().Uint64() >> 1)
+}
+
+// moveBots lets the bots bid and play for as long as it is their turn. Like
+// the turn timers they wait until every player has acknowledged the last
+// broadcast.
+func (r *Room) moveBots(now time.Time) {
+	for len(r.bots) > 0 && !r.awaitingAcks(now) {
+		switch r.game.State.Phase {
+		case models.PhaseBidding:
+			if r.redealOpen(now) || !r.botBid() {
+				return
+			}
+		case models.PhasePlaying:
+			if !r.botPlay() {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// botBid bids for the current bidder if they are a bot, reporting whether a
+// bid was placed
+func (r *Room) botBid() bool {
+	current := engine.CurrentPlayer(&r.game.State)
+	if current == nil {
+		return false
+	}
+	b, isBot := r.bots[current.ID]
+	if !isBot {
+		return false
+	}
+
+	if err := r.handleBid(b.ID, b.Bid(r.game.State)); err != nil {
+		fmt.Printf("Bot %s failed to bid: %v\n", b.ID, err)
+		return false
+	}
+	return true
+}
+
+// botPlay plays for the current player if they are a bot, reporting whether
+// a card was played. Hard bots search for their card on a goroutine of their
+// own so the room keeps answering meanwhile; their card comes back as a
+// commandBotPlay.
+func (r *Room) botPlay() bool {
+	current := engine.CurrentPlayer(&r.game.State)
+	if current == nil || r.thinking {
+		return false
+	}
+	b, isBot := r.bots[current.ID]
+	if !isBot {
+		return false
+	}
+
+	if b.Level == bot.Hard {
+		// Nothing else can be applied until the bot plays, so the bot is
+		// not observing events while it searches
+		r.thinking = true
+		state := r.game.State.Clone()
+		go func() {
+			card, err := b.Play(state)
+			r.send(roomCommand{kind: commandBotPlay, playerID: b.ID, card: card, botErr: err})
+		}()
+		return false
+	}
+
+	card, err := b.Play(r.game.State)
+	return r.handleBotPlay(b.ID, card, err) == nil
+}
+
+// handleBotPlay plays the card a bot chose, or reports why it could not
+// choose one
+func (r *Room) handleBotPlay(playerID string, card models.Card, err error) error {
+	r.thinking = false
+	if err == nil {
+		err = r.handlePlay(playerID, card)
+	}
+	if err != nil {
+		fmt.Printf("Bot %s failed to play: %v\n", playerID, err)
+	}
+	return err
+}
@@ -1,14 +1,12 @@
 package services
 
 import (
-	//"encoding/json"
+	"encoding/json"
 	"dealer-backend/internal/models"
 	"fmt"
 
 	// "log"
 	"sync"
-
-	"github.com/gorilla/websocket"
 )
 
 // Message represents a chat message structure
@@ -18,7 +16,7 @@ type Message struct {
 }
 
 // StartChatService handles chat communication between two players
-func StartChatService(game models.Game, playerConnections *models.PlayerConnections) {
+func StartChatService(game models.Game, playerConnections *PlayerConnections) {
     // Retrieve connections for both players
     player1Conn, exists1 := playerConnections.GetPlayerConnection(game.Players[0])
     player2Conn, exists2 := playerConnections.GetPlayerConnection(game.Players[1])
@@ -36,17 +34,11 @@ func StartChatService(game models.Game, playerConnections *models.PlayerConnecti
     var wg sync.WaitGroup
 
     // Function to handle message reading and sending
-    readMessages := func(conn *websocket.Conn, otherConn *websocket.Conn, playerID string) {
+    readMessages := func(conn *Client, otherConn *Client, playerID string) {
         defer wg.Done() // Notify when done
 
 		fmt.Println("Listening for player", playerID)
-        for {
-            _, msg, err := conn.ReadMessage()
-            if err != nil {
-                fmt.Println("Error reading message:", err)
-                break // Exit if there's an error
-            }
-
+        conn.Route(func(msg []byte) {
             // Construct the message object to send
             message := Message{
                 SenderID: playerID,
@@ -55,11 +47,19 @@ func StartChatService(game models.Game, playerConnections *models.PlayerConnecti
 
 
             // Send the message to the other player
-            if err := otherConn.WriteJSON(message); err != nil {
+            data, err := json.Marshal(message)
+            if err != nil {
+                fmt.Println("Error marshaling message:", err)
+                return
+            }
+            if err := otherConn.Send(data); err != nil {
                 fmt.Println("Error sending message:", err)
-                break // Exit if there's an error
+                conn.Close() // Stop listening if there's an error
             }
-        }
+        })
+
+        // Exit once the connection is gone
+        <-conn.Done()
     }
 
     wg.Add(2) // We have two players
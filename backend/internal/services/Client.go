@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Errors returned by Client.Send
+var (
+	ErrClientClosed = errors.New("connection is closed")
+	ErrSlowConsumer = errors.New("player is not reading their messages")
+)
+
+const (
+	clientQueueSize = 64               // Messages queued per connection before it counts as too slow
+	writeWait       = 10 * time.Second // Time allowed to write one message
+	heartbeats      = 3                // Pings sent per HeartbeatTimeout, so one lost ping is no harm
+)
+
+// HeartbeatTimeout is how long a connection may stay silent, answering no
+// ping and sending nothing, before the player counts as disconnected
+var HeartbeatTimeout = 30 * time.Second
+
+// Client is a player's WebSocket connection. gorilla/websocket allows only
+// one reader and one writer per connection, so the client runs one goroutine
+// of each: everything sent goes through a queue the writer drains, and the
+// reader hands each message to the handler set with Route.
+type Client struct {
+	PlayerID string
+	conn     *websocket.Conn
+	send     chan []byte
+	done     chan struct{}
+	close    sync.Once
+
+	heartbeat time.Duration // HeartbeatTimeout when the client was made
+
+	mu      sync.Mutex
+	handler func(raw []byte) // Receives the player's messages, dropped while nil
+}
+
+// NewClient wraps conn, which must not be read or written directly any more,
+// and starts its reader and writer
+func NewClient(playerID string, conn *websocket.Conn) *Client {
+	c := &Client{
+		PlayerID:  playerID,
+		conn:      conn,
+		send:      make(chan []byte, clientQueueSize),
+		done:      make(chan struct{}),
+		heartbeat: HeartbeatTimeout,
+	}
+	go c.writePump()
+	go c.readPump()
+	return c
+}
+
+// Route hands the player's messages to handler from now on, replacing the
+// previous handler. handler runs on the client's reader goroutine.
+func (c *Client) Route(handler func(raw []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+// Send queues data to be written without waiting for the player. A player
+// whose queue is full is disconnected rather than holding up the sender.
+func (c *Client) Send(data []byte) error {
+	select {
+	case <-c.done:
+		return ErrClientClosed
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	default:
+		log.Printf("Disconnecting player %s: %d messages behind\n", c.PlayerID, len(c.send))
+		c.Close()
+		return ErrSlowConsumer
+	}
+}
+
+// Close writes out what is queued and closes the connection. It is safe to
+// call more than once.
+func (c *Client) Close() {
+	c.close.Do(func() { close(c.done) })
+}
+
+// Done is closed once the client is closed, whether on purpose, because the
+// player went away or because their heartbeat stopped
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// readPump is the only goroutine reading from the connection. Every pong and
+// every message pushes the read deadline back, so a connection that stays
+// silent for its heartbeat timeout fails its read and the client closes.
+func (c *Client) readPump() {
+	defer c.Close()
+
+	c.conn.SetReadDeadline(time.Now().Add(c.heartbeat))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.heartbeat))
+	})
+
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			log.Printf("Lost connection of player %s: %v\n", c.PlayerID, err)
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(c.heartbeat))
+
+		c.mu.Lock()
+		handler := c.handler
+		c.mu.Unlock()
+		if handler == nil {
+			log.Printf("Dropping message from player %s, nothing is listening\n", c.PlayerID)
+			continue
+		}
+		handler(raw)
+	}
+}
+
+// writePump is the only goroutine writing to the connection
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.heartbeat / heartbeats)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data := <-c.send:
+			if err := c.write(data); err != nil {
+				log.Printf("Error writing to player %s: %v\n", c.PlayerID, err)
+				c.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				log.Printf("Ping to player %s failed: %v\n", c.PlayerID, err)
+				c.Close()
+				return
+			}
+		case <-c.done:
+			c.flush()
+			return
+		}
+	}
+}
+
+// flush writes what is still queued, then says goodbye
+func (c *Client) flush() {
+	for {
+		select {
+		case data := <-c.send:
+			if err := c.write(data); err != nil {
+				return
+			}
+		default:
+			closing := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+			c.conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+func (c *Client) write(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
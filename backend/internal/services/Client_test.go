@@ -0,0 +1,197 @@
+package services
+
+import (
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestClientSendsFromManyGoroutinesInOrder(t *testing.T) {
+	server, client := connectTestPlayer(t, "p1")
+
+	const senders, each = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(sender int) {
+			defer wg.Done()
+			for j := 0; j < each; j++ {
+				if err := server.Send([]byte(fmt.Sprintf(`{"sender":%d,"n":%d}`, sender, j))); err != nil {
+					t.Errorf("send: %v", err)
+					return
+				}
+				time.Sleep(50 * time.Microsecond) // Stay within the queue
+			}
+		}(i)
+	}
+
+	// Each sender's messages arrive whole and in the order sent
+	next := make(map[int]int)
+	for i := 0; i < senders*each; i++ {
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, raw, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		var msg struct{ Sender, N int }
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("unmarshal %s: %v", raw, err)
+		}
+		if msg.N != next[msg.Sender] {
+			t.Fatalf("sender %d: got message %d, want %d", msg.Sender, msg.N, next[msg.Sender])
+		}
+		next[msg.Sender]++
+	}
+	wg.Wait()
+}
+
+func TestSlowConsumersAreDisconnected(t *testing.T) {
+	server, _ := connectTestPlayer(t, "p1") // Never reads
+
+	// Large messages fill the socket buffers, then the queue
+	payload := make([]byte, 256<<10)
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		err = server.Send(payload)
+	}
+	if !errors.Is(err, ErrSlowConsumer) {
+		t.Fatalf("send to a player who never reads: %v, want ErrSlowConsumer", err)
+	}
+
+	select {
+	case <-server.Done():
+	default:
+		t.Fatal("slow consumer still connected")
+	}
+	if err := server.Send([]byte(`{}`)); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("send after disconnect: %v, want ErrClientClosed", err)
+	}
+}
+
+func TestBroadcastReachesEveryoneDespiteFailures(t *testing.T) {
+	pc := NewPlayerConnections()
+	gone, _ := connectTestPlayer(t, "gone")
+	here, client := connectTestPlayer(t, "here")
+	gone.Close()
+	pc.AddPlayer(gone)
+	pc.AddPlayer(here)
+
+	err := pc.BroadcastMessage(protocol.Notice("hello"))
+	if !errors.Is(err, ErrClientClosed) {
+		t.Errorf("broadcast error = %v, want ErrClientClosed for the closed player", err)
+	}
+
+	// The player list goes out too, skip it
+	for {
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, raw, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("notice not delivered: %v", err)
+		}
+		var envelope struct {
+			Type string
+			Data string
+		}
+		if json.Unmarshal(raw, &envelope) == nil && envelope.Type == "message" {
+			if envelope.Data != "hello" {
+				t.Errorf("notice = %q, want hello", envelope.Data)
+			}
+			return
+		}
+	}
+}
+
+// readUntil reads from conn until a message of messageType arrives
+func readUntil(t *testing.T, conn *websocket.Conn, messageType string) json.RawMessage {
+	t.Helper()
+	for {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("no %s received: %v", messageType, err)
+		}
+		var envelope struct {
+			Type string
+			Data json.RawMessage
+		}
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			t.Fatalf("unmarshal %s: %v", raw, err)
+		}
+		if envelope.Type == messageType {
+			return envelope.Data
+		}
+	}
+}
+
+func TestSilentConnectionsMissTheirHeartbeat(t *testing.T) {
+	defer func(timeout time.Duration) { HeartbeatTimeout = timeout }(HeartbeatTimeout)
+	HeartbeatTimeout = 150 * time.Millisecond
+
+	silent, _ := connectTestPlayer(t, "silent") // Never reads, so never answers a ping
+	alive, client := connectTestPlayer(t, "alive")
+	go func() {
+		// Reading answers the pings
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-silent.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("silent connection still open")
+	}
+
+	time.Sleep(3 * HeartbeatTimeout)
+	select {
+	case <-alive.Done():
+		t.Fatal("connection answering pings was closed")
+	default:
+	}
+}
+
+func TestRoomsAnnouncePresenceChanges(t *testing.T) {
+	defer func(timeout time.Duration) { HeartbeatTimeout = timeout }(HeartbeatTimeout)
+	HeartbeatTimeout = 150 * time.Millisecond
+
+	r := startTestRooms(t, 1)[0]
+	lost, watcher := r.players[0], r.players[1]
+	lostConn, _ := connectTestPlayer(t, lost)
+	watcherConn, watcherClient := connectTestPlayer(t, watcher)
+	for _, conn := range []*Client{lostConn, watcherConn} {
+		if err := r.Join(conn.PlayerID, conn); err != nil {
+			t.Fatalf("join: %v", err)
+		}
+		StartMessageRouter(r.ID, map[string]*Client{conn.PlayerID: conn})
+	}
+
+	// The lost player stops answering pings
+	var disconnected protocol.PlayerDisconnected
+	if err := json.Unmarshal(readUntil(t, watcherClient, "player_disconnected"), &disconnected); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if disconnected.PlayerID != lost || disconnected.Seconds != int(DefaultRoomOptions().GracePeriod/time.Second) {
+		t.Errorf("player_disconnected %+v, want %s held for the room's grace period", disconnected, lost)
+	}
+
+	back, backClient := connectTestPlayer(t, lost)
+	if !ResumePlayer(back) {
+		t.Fatal("player could not resume their seat")
+	}
+	readUntil(t, backClient, "resume")
+	var reconnected protocol.PlayerReconnected
+	if err := json.Unmarshal(readUntil(t, watcherClient, "player_reconnected"), &reconnected); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if reconnected.PlayerID != lost {
+		t.Errorf("player_reconnected for %s, want %s", reconnected.PlayerID, lost)
+	}
+}
@@ -1,65 +1,75 @@
 package services
 
 import (
-	"encoding/json"
+	"dealer-backend/internal/protocol"
+	"errors"
 	"log"
-
-	"github.com/gorilla/websocket"
 )
 
-type BidMessage struct {
-	Type     string `json:"type"`
-	PlayerID string `json:"playerId"`
-	Bid      int    `json:"bid,omitempty"` // Optional field for bid
-}
+// StartMessageRouter hands the messages of every player's connection to the
+// room of gameID, and tells the room when a connection is gone
+func StartMessageRouter(gameID string, connections map[string]*Client) {
+	for playerID, conn := range connections {
+		playerID, conn := playerID, conn
+		conn.Route(func(rawMessage []byte) {
+			routeMessage(gameID, playerID, rawMessage)
+		})
 
-var (
-	bidChannel = make(chan BidMessage, 8)
-	ackChannel  = make(chan string, 8)
-)
+		go func() {
+			<-conn.Done()
+			log.Printf("Connection of player %s to game %s closed\n", playerID, gameID)
+			if r := getRoom(gameID); r != nil {
+				r.Leave(playerID, conn)
+			}
+		}()
+	}
+}
 
+// routeMessage hands a message from playerID to the room playing gameID.
+// Messages from players who are not seated in that room are dropped so rooms
+// never see each other's traffic.
+func routeMessage(gameID string, playerID string, rawMessage []byte) {
+	r := getRoom(gameID)
+	if r == nil {
+		log.Printf("Dropping message from player %s for unknown game %s\n", playerID, gameID)
+		return
+	}
+	if !r.seated(playerID) {
+		log.Printf("Dropping message from player %s, not seated in game %s\n", playerID, gameID)
+		return
+	}
 
-func StartMessageRouter(connections map[string]*websocket.Conn) {
-	
-	// Start a goroutine to handle each player's connection and route messages
-	for playerID, conn := range connections {
-		go func(playerID string, conn *websocket.Conn) {
-			defer conn.Close()
-			log.Println("Waiting for messagess..")
-			for {
-				// Read message from the WebSocket connection
-				_, rawMessage, err := conn.ReadMessage()
-				if err != nil {
-					log.Printf("Error reading message from player %s: %v\n", playerID, err)
-					return
-				}
+	// Malformed and unknown messages are answered, not just logged
+	msg, err := protocol.Decode(rawMessage)
+	if err != nil {
+		log.Printf("Refusing message from player %s: %v\n", playerID, err)
+		r.Reply(playerID, protocol.ErrorReply(err))
+		return
+	}
 
+	// Route messages based on their type
+	// The player ID comes from the connection, never from the message
+	switch cmd := msg.(type) {
+	case *protocol.PlayCard:
+		err = r.Play(playerID, cmd.Card)
 
-				log.Println("rawMessage received..", rawMessage)
+	case *protocol.PlaceBid:
+		err = r.Bid(playerID, cmd.Bid)
 
-				// Unmarshal message into a BidMessage struct
-				var msg BidMessage
-				if err := json.Unmarshal(rawMessage, &msg); err != nil {
-					log.Printf("Failed to unmarshal message from player %s: %v\n", playerID, err)
-					continue
-				}
+	case *protocol.RequestRedeal:
+		err = r.Redeal(playerID)
 
-				// Route messages based on the Type field
-				switch msg.Type {
-				case "placebid":
-					// Set the player ID and send to bidChannel
-					msg.PlayerID = playerID
-					bidChannel <- msg
+	case *protocol.Acknowledgment:
+		err = r.Ack(playerID)
 
-				case "acknowledgment":
-					// Send player ID to ackChannel
-					ackChannel <- playerID
+	case *protocol.Back:
+		err = r.Back(playerID)
 
-				default:
-					log.Printf("Unknown message type from player %s: %v\n", playerID, msg.Type)
-				}
-			}
-		}(playerID, conn)
+	default:
+		log.Printf("No handler for message type %s from player %s\n", msg.MessageType(), playerID)
+		err = &protocol.DecodeError{Code: protocol.CodeUnknownType, Type: msg.MessageType(), Err: errors.New("not accepted during a game")}
 	}
 
+	// Every command is answered, so the client can match the outcome to it
+	r.Reply(playerID, protocol.Reply(msg, err))
 }
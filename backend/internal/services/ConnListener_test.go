@@ -0,0 +1,193 @@
+package services
+
+import (
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startTestRooms runs numRooms single-deal games without any connections
+func startTestRooms(t *testing.T, numRooms int) []*Room {
+	t.Helper()
+	rooms := make([]*Room, numRooms)
+	for i := range rooms {
+		playerIDs := make([]string, 4)
+		for j := range playerIDs {
+			playerIDs[j] = fmt.Sprintf("room%d-player%d", i, j)
+		}
+		game := &models.Game{
+			GameID:  fmt.Sprintf("game-%d", i),
+			Players: playerIDs,
+			State: models.GameState{
+				Seats:    models.NewSeats(playerIDs),
+				Turn:     1,
+				Phase:    models.PhaseDealing,
+				NumDeals: 1,
+			},
+		}
+		rooms[i] = newRoom(game, nil)
+		registerRoom(rooms[i])
+		go rooms[i].run()
+	}
+	t.Cleanup(func() {
+		for _, r := range rooms {
+			unregisterRoom(r)
+		}
+	})
+	return rooms
+}
+
+func TestConcurrentRoomsReceiveOnlyTheirOwnMessages(t *testing.T) {
+	const numRooms = 12
+	rooms := startTestRooms(t, numRooms)
+
+	var wg sync.WaitGroup
+	for i, r := range rooms {
+		other := rooms[(i+1)%numRooms]
+		wg.Add(1)
+		// Players bid in turn, so each room's players take turns on one goroutine
+		go func(r, other *Room, bid int) {
+			defer wg.Done()
+			for _, playerID := range r.players {
+				// Messages aimed at a room the player is not seated in are dropped
+				routeMessage(other.ID, playerID, []byte(`{"type":"placebid","bid":13}`))
+				routeMessage(other.ID, playerID, []byte(`{"type":"acknowledgment"}`))
+
+				// The playerId in the payload must be ignored in favour of the connection's
+				routeMessage(r.ID, playerID, []byte(fmt.Sprintf(`{"type":"placebid","playerId":"intruder","bid":%d}`, bid)))
+				routeMessage(r.ID, playerID, []byte(`{"type":"acknowledgment"}`))
+			}
+		}(r, other, i+1)
+	}
+	wg.Wait()
+
+	for i, r := range rooms {
+		view, err := r.Snapshot(r.players[0])
+		if err != nil {
+			t.Fatalf("room %d snapshot: %v", i, err)
+		}
+
+		if len(view.State.Bids) != len(r.players) {
+			t.Errorf("room %d has %d bids, want %d: %v", i, len(view.State.Bids), len(r.players), view.State.Bids)
+		}
+		for playerID, bid := range view.State.Bids {
+			if !r.seated(playerID) {
+				t.Errorf("room %d got bid from foreign player %q", i, playerID)
+			}
+			if bid != i+1 {
+				t.Errorf("room %d got bid %d from %s, want %d", i, bid, playerID, i+1)
+			}
+		}
+		if view.State.Phase != models.PhasePlaying {
+			t.Errorf("room %d is in phase %q after all bids, want %q", i, view.State.Phase, models.PhasePlaying)
+		}
+	}
+}
+
+func TestMessagesForUnknownGamesAreDropped(t *testing.T) {
+	rooms := startTestRooms(t, 1)
+	r := rooms[0]
+
+	routeMessage("no-such-game", r.players[0], []byte(`{"type":"placebid","bid":3}`))
+	routeMessage(r.ID, "stranger", []byte(`{"type":"placebid","bid":3}`))
+
+	view, err := r.Snapshot(r.players[0])
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if len(view.State.Bids) != 0 {
+		t.Errorf("bids recorded from dropped messages: %v", view.State.Bids)
+	}
+}
+
+// connectTestPlayer returns both ends of playerID's WebSocket: the server
+// side to seat in a room and the client side to read what the room sends
+func connectTestPlayer(t *testing.T, playerID string) (*Client, *websocket.Conn) {
+	t.Helper()
+	upgraded := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, req, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		upgraded <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	server := NewClient(playerID, <-upgraded)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return server, client
+}
+
+func TestCommandsGetRepliesMatchingTheirRequestID(t *testing.T) {
+	rooms := startTestRooms(t, 1)
+	r := rooms[0]
+	playerID := r.players[0] // First to bid, left of the dealer
+	server, client := connectTestPlayer(t, playerID)
+	if err := r.Join(playerID, server); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	tests := []struct {
+		raw       string
+		requestID string
+		reply     string
+		code      string
+	}{
+		{`{"type":"placebid","bid":`, "", "error", protocol.CodeMalformed},
+		{`{"type":"playcard?","requestId":"a"}`, "a", "error", protocol.CodeUnknownType},
+		{`{"type":"placebid","requestId":"b","bid":14}`, "b", "error", protocol.CodeBidTooHigh},
+		{`{"type":"placebid","requestId":"c","bid":2}`, "c", "ok", ""},
+		{`{"type":"placebid","requestId":"d","bid":2}`, "d", "error", protocol.CodeAlreadyBid},
+		{`{"type":"playcard","requestId":"e","card":{"rank":"A","suit":"S"}}`, "e", "error", protocol.CodeWrongPhase},
+	}
+	var lastSeq uint64
+	for _, tt := range tests {
+		routeMessage(r.ID, playerID, []byte(tt.raw))
+
+		// Skip the broadcasts, e.g. on joining or bidding
+		for {
+			client.SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, raw, err := client.ReadMessage()
+			if err != nil {
+				t.Fatalf("no reply to %s: %v", tt.raw, err)
+			}
+			var envelope struct {
+				Type string
+				Seq  uint64
+				Data protocol.Error
+			}
+			if err := json.Unmarshal(raw, &envelope); err != nil {
+				t.Fatalf("unmarshal %s: %v", raw, err)
+			}
+			if envelope.Seq <= lastSeq {
+				t.Errorf("seq %d after %d", envelope.Seq, lastSeq)
+			}
+			lastSeq = envelope.Seq
+			if envelope.Type != "ok" && envelope.Type != "error" {
+				continue
+			}
+			if envelope.Type != tt.reply || envelope.Data.Code != tt.code || envelope.Data.RequestID != tt.requestID {
+				t.Errorf("reply to %s = %s, want %s %q for request %q", tt.raw, raw, tt.reply, tt.code, tt.requestID)
+			}
+			break
+		}
+	}
+}
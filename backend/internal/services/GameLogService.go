@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// GameLogDir is where the event log of each finished game is written, one
+// JSON file per game. Logs are not written when it is empty.
+var GameLogDir = ""
+
+// saveLog writes the room's event log to GameLogDir
+func (r *Room) saveLog() {
+	if GameLogDir == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"gameId":  r.ID,
+		"players": r.players,
+		"entries": r.log.Entries(),
+	}, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding event log of game %s: %v\n", r.ID, err)
+		return
+	}
+
+	if err := os.MkdirAll(GameLogDir, 0o755); err != nil {
+		log.Printf("Error creating game log directory: %v\n", err)
+		return
+	}
+	path := filepath.Join(GameLogDir, r.ID+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Printf("Error writing event log of game %s: %v\n", r.ID, err)
+		return
+	}
+	fmt.Println("Event log of game", r.ID, "written to", path)
+}
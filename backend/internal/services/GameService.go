@@ -2,83 +2,85 @@ package services
 
 import (
 	//"encoding/json"
+	crand "crypto/rand"
+	"dealer-backend/internal/bot"
 	"dealer-backend/internal/models"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"time"
-
-	"github.com/gorilla/websocket"
 	//"net/http"
 )
 
-// StartMatchmaking handles the matchmaking logic for 4 players
-func StartMatchmaking(players *models.PlayerConnections, playerID string) {
-	// Main loop for matching players
-	for {
-		playerList := players.GetPlayerList()
-		fmt.Println("connected players:", len(playerList))
+// TableSize is the number of players seated at a new game, between
+// models.MinPlayers and models.MaxPlayers
+var TableSize = 4
 
-		// Check if there are enough players to start a game
-		if len(playerList) < 4 {
-			time.Sleep(1 * time.Second) // Wait before checking again
-			continue 
-		}
+// BotFillWait is how long the longest waiting player is matched with no one
+// before the empty seats are given to bots. Zero never fills seats with bots.
+var BotFillWait = 30 * time.Second
 
-		fmt.Println("enough players to start a game")
-
-		// Create a map to hold selected players with their connections
-		selectedPlayers := make(map[string]*websocket.Conn)
-		conn, exists := players.GetPlayerConnection(playerID)
-		if exists {
-			selectedPlayers[playerID] = conn
-		}
-
-		// Select 3 random opponents
-		for _, pid := range playerList {
-			if pid != playerID {
-				conn, exists := players.GetPlayerConnection(pid)
-				if exists {
-					selectedPlayers[pid] = conn
-					if len(selectedPlayers) == 4 {
-						break
-					}
-				}
-			}
-		}
-
-		// Check if enough players were found
-		if len(selectedPlayers) < 4 {
-			time.Sleep(1 * time.Second) // Wait before checking again
-			continue
-		}
+// BotLevel is the difficulty of the bots filling empty seats
+var BotLevel = bot.Medium
 
-		// Create a slice of player IDs for the game struct
-		playerIDs := make([]string, 0, 4)
-		for pid := range selectedPlayers {
-			playerIDs = append(playerIDs, pid)
-		}
+// GameSetup is how a new game is played
+type GameSetup struct {
+	Seats    int // Seats at the table; those the players don't take go to bots
+	NumDeals int
+	Rules    models.RuleSet
+	Options  RoomOptions
+	Lobby    *PlayerConnections // Takes the players back once the game is over, when set
+}
 
-		// Create a new game
-		gameID := fmt.Sprintf("game-%d", rand.Intn(100000)) // Generate a random game ID
+// DefaultGameSetup returns the setup of games started by matchmaking
+func DefaultGameSetup() GameSetup {
+	return GameSetup{
+		Seats:    TableSize,
+		NumDeals: models.DefaultNumDeals,
+		Rules:    models.StrictCallBreak,
+		Options:  DefaultRoomOptions(),
+	}
+}
 
-		game := models.Game{
-			GameID:  gameID,
-			Players: playerIDs,
-			State: models.GameState{
-				Player1: models.Player{ID: playerIDs[0], Health: 100},
-				Player2: models.Player{ID: playerIDs[1], Health: 100},
-				Player3: models.Player{ID: playerIDs[2], Health: 100},
-				Player4: models.Player{ID: playerIDs[3], Health: 100},
-				Turn:    1, // Set initial turn to player 1
-			},
+// newGameID returns an ID no running room has. IDs are random rather than
+// counted so they don't repeat across restarts either, which keeps the
+// saved game logs apart.
+func newGameID() string {
+	for {
+		var b [8]byte
+		crand.Read(b[:])
+		gameID := "game-" + hex.EncodeToString(b[:])
+		if getRoom(gameID) == nil {
+			return gameID
 		}
+	}
+}
 
-		fmt.Println("Starting chatService....")
-		createRoom(&game, selectedPlayers)
+// startGame seats the players, in the order given, fills the seats left over
+// with bots and starts the room
+func startGame(playerIDs []string, clients map[string]*Client, setup GameSetup) *Room {
+	// Create a new game
+	gameID := newGameID()
+	playerIDs = append([]string(nil), playerIDs...)
 
-		// Notify players about the new game
-		fmt.Printf("Starting game %s with players: %v\n", gameID, playerIDs)
+	// Bots take the seats nobody came for
+	bots := newBots(gameID, len(playerIDs)+1, setup.Seats+1, BotLevel)
+	for _, b := range bots {
+		playerIDs = append(playerIDs, b.ID)
+	}
 
-		break // Exit the loop after creating a game
+	game := models.Game{
+		GameID:  gameID,
+		Players: playerIDs,
+		State: models.GameState{
+			Seats:    models.NewSeats(playerIDs),
+			Dealer:   rand.Intn(len(playerIDs)) + 1, // The first dealer is drawn, then the deal rotates
+			Phase:    models.PhaseDealing,
+			NumDeals: setup.NumDeals,
+			Rules:    setup.Rules,
+		},
 	}
+
+	fmt.Printf("Starting game %s with players: %v\n", gameID, playerIDs)
+	return createRoom(&game, clients, setup.Lobby, setup.Options, bots...)
 }
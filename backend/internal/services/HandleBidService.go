@@ -1,123 +1,68 @@
 package services
 
 import (
-	"dealer-backend/internal/models"
-	// "encoding/json"
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/protocol"
 	"fmt"
-	"log"
-	// "sync"
 	"time"
-
-	"github.com/gorilla/websocket"
 )
 
-
-// type BidMessage struct {
-// 	Type string `json:"type"`
-// 	PlayerID string `json:"playerId"`
-// 	Bid      int    `json:"bid"`
-
-// }
-
-
-func WaitForAllBids(game *models.Game, connections map[string]*websocket.Conn) map[string]int {
-	
-	timeout := time.After(120 * time.Second)
-	expectedBidCount := len(connections)
-
-	bids := make(map[string]int)
-	acknowledgedPlayers := make(map[string]bool)
-
-	fmt.Println("Starting to listen for bids from all players...")
-
-	// Start the main loop to collect bids or timeout in a separate goroutine
-	
-	func() {
-		bidCount := 0
-		ticker := time.NewTicker(60 * time.Second) // Create a ticker that ticks every 10 seconds
-		defer ticker.Stop() // Ensure the ticker is stopped when done
-
-		for {
-			select {
-			case bid, ok := <-bidChannel:
-				if !ok {
-					fmt.Println("Bid channel closed.")
-					return
-				}
-
-				SetPlayerBid(game, bid.PlayerID, bid.Bid)
-				bids[bid.PlayerID] = bid.Bid
-				acknowledgedPlayers[bid.PlayerID] = true
-				bidCount++
-				fmt.Printf("Processed bid from player %s: %d\n", bid.PlayerID, bid.Bid)
-
-				// Send bid update notification
-				BroadcastAndAck(game, connections, "gamestate")
-
-				if bidCount == expectedBidCount {
-					fmt.Println("All bids received. Broadcasting bidding complete.")
-					BroadcastGameState(game, connections, "biddingcomplete")
-					BroadcastAndAck(game, connections, "gamestate")
-					return
-				}
-			case <-timeout:
-				fmt.Println("Bidding timeout. Players who did not bid:")
-				for playerID := range connections {
-					if !acknowledgedPlayers[playerID] {
-						fmt.Printf("Missing bid from player: %s\n", playerID)
-					}
-				}
-				return
-			case <-ticker.C: // Listen for ticker ticks
-				// Send a reminder message only to clients who have not submitted bids
-				for playerID, conn := range connections {
-					if !acknowledgedPlayers[playerID] { // Check if the player has not submitted a bid
-						msg := map[string]interface{}{
-							"type": "updatebid",
-							"data": map[string]string{
-								"message": "Please update your bid.",
-							},
-						}
-						if err := conn.WriteJSON(msg); err != nil {
-							log.Printf("Error sending update bid message to player %s: %v\n", playerID, err)
-						} else {
-							fmt.Printf("Sent update bid message to player %s.\n", playerID)
-						}
-					}
-				}
-			}
-		}
-	}()
-				
-	
-	return bids
+// handleBid records a bid for playerID and tells everyone about it
+func (r *Room) handleBid(playerID string, amount int) error {
+	events, err := r.apply(engine.Bid{PlayerID: playerID, Amount: amount})
+	if err != nil {
+		// The player keeps their turn, the error reply tells them why
+		fmt.Printf("Rejected bid from player %s: %v\n", playerID, err)
+		return err
+	}
+	fmt.Printf("Processed bid from player %s: %d\n", playerID, amount)
+
+	// Send bid update notification
+	r.broadcastAndAck("gamestate")
+	r.afterEvents(events)
+	return nil
 }
 
-func SetPlayerBid(game *models.Game, playerID string, bidAmount int) error {
-    // Ensure the Bids map is initialized
-    if game.State.Bids == nil {
-        game.State.Bids = make(map[string]int)
-    }
-
-    // Set the bid in the Bids map for the player
-    game.State.Bids[playerID] = bidAmount
-
-    // Update the Player struct's Bid field
-    switch playerID {
-    case game.State.Player1.ID:
-        game.State.Player1.Bid = bidAmount
-    case game.State.Player2.ID:
-        game.State.Player2.Bid = bidAmount
-    case game.State.Player3.ID:
-        game.State.Player3.Bid = bidAmount
-    case game.State.Player4.ID:
-        game.State.Player4.Bid = bidAmount
-    default:
-        return fmt.Errorf("player with ID %s not found", playerID)
-    }
-
-    return nil
+// startBidTimer gives the player whose turn it is to bid a full timer, which
+// starts running once the redeal window has closed
+func (r *Room) startBidTimer(now time.Time) {
+	if r.redealOpen(now) {
+		now = r.redealUntil
+	}
+	r.bidDeadline = now.Add(r.options.BidDuration)
+	r.nextReminder = now.Add(bidReminderEvery)
 }
 
+// tickBidding bids for the current bidder once their deadline has passed and
+// reminds them in the meantime
+func (r *Room) tickBidding(now time.Time) {
+	bidder := engine.CurrentPlayer(&r.game.State)
+	if bidder == nil || r.redealOpen(now) {
+		return
+	}
+
+	// Nobody is waiting for an absent player, time them out straight away
+	if r.absent(bidder.ID) || now.After(r.bidDeadline) {
+		fmt.Println("Bidding timeout for player", bidder.ID)
+		r.timeoutBid(bidder.ID)
+		return
+	}
+
+	if now.Before(r.nextReminder) {
+		return
+	}
+	r.nextReminder = now.Add(bidReminderEvery)
+	r.sendTo(bidder.ID, protocol.UpdateBid{Message: "Please update your bid."})
+}
 
-
+// timeoutBid places the default bid for playerID as if their timer ran out
+func (r *Room) timeoutBid(playerID string) {
+	events, err := r.apply(engine.Timeout{PlayerID: playerID})
+	if err != nil {
+		fmt.Printf("Error applying bid timeout for %s: %v\n", playerID, err)
+		return
+	}
+	r.noteTimeout(playerID)
+	r.broadcast("gamestate")
+	r.afterEvents(events)
+}
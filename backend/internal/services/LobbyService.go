@@ -0,0 +1,273 @@
+package services
+
+import (
+	"dealer-backend/internal/protocol"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PlayerConnections holds the connections of the players waiting in the lobby
+type PlayerConnections struct {
+	mu         sync.RWMutex
+	players    map[string]*Client
+	seq        map[string]uint64 // Lobby messages sent to each player
+	matchmaker *Matchmaker       // Handles queue messages, when set
+	rooms      *PrivateRooms     // Handles private room messages, when set
+}
+
+// Create a new PlayerConnections object
+func NewPlayerConnections() *PlayerConnections {
+	return &PlayerConnections{
+		players: make(map[string]*Client),
+		seq:     make(map[string]uint64),
+	}
+}
+
+func (pc *PlayerConnections) AddPlayer(client *Client) {
+	playerID := client.PlayerID
+	fmt.Println("Starting AddPlayer for", playerID)
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	fmt.Println("Acquired lock for", playerID)
+	if old, exists := pc.players[playerID]; exists && old != client {
+		old.Close() // The player connected again
+	}
+	pc.players[playerID] = client
+	fmt.Println("Added", playerID, "to players map")
+
+	// The lobby answers the player until a room takes over their messages,
+	// and forgets them once their connection is gone
+	client.Route(func(raw []byte) { pc.handleMessage(client, raw) })
+	go func() {
+		<-client.Done()
+		pc.removeClient(client)
+	}()
+
+	fmt.Println("About to call broadcastPlayerList for", playerID)
+	go pc.broadcastPlayerList() // Call broadcastPlayerList in a new goroutine
+	fmt.Println("Finished AddPlayer for", playerID)
+}
+
+func (pc *PlayerConnections) RemovePlayer(playerID string) {
+	fmt.Println("removing player", playerID)
+	pc.mu.Lock()
+	delete(pc.players, playerID)
+	delete(pc.seq, playerID)
+	rooms := pc.rooms
+	pc.mu.Unlock()
+	if rooms != nil {
+		rooms.Leave(playerID) // Not an error if they were in none
+	}
+	pc.broadcastPlayerList() // Takes the lock itself
+}
+
+// removeClient removes the player of client, unless they have connected
+// again since
+func (pc *PlayerConnections) removeClient(client *Client) {
+	pc.mu.RLock()
+	current := pc.players[client.PlayerID] == client
+	pc.mu.RUnlock()
+	if current {
+		pc.RemovePlayer(client.PlayerID)
+	}
+}
+
+func (pc *PlayerConnections) setMatchmaker(m *Matchmaker) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.matchmaker = m
+}
+
+func (pc *PlayerConnections) setPrivateRooms(pr *PrivateRooms) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.rooms = pr
+}
+
+// inPrivateRoom tells whether playerID waits in a private room, or is
+// joining one
+func (pc *PlayerConnections) inPrivateRoom(playerID string) bool {
+	pc.mu.RLock()
+	rooms := pc.rooms
+	pc.mu.RUnlock()
+	if rooms == nil {
+		return false
+	}
+	return rooms.holds(playerID)
+}
+
+// handleMessage answers a message sent from the lobby, where players can
+// queue up for a game or gather in a private room
+func (pc *PlayerConnections) handleMessage(client *Client, raw []byte) {
+	msg, err := protocol.Decode(raw)
+	if err != nil {
+		pc.sendTo(client, protocol.ErrorReply(err))
+		return
+	}
+
+	pc.mu.RLock()
+	matchmaker, rooms := pc.matchmaker, pc.rooms
+	pc.mu.RUnlock()
+
+	switch msg.(type) {
+	case *protocol.Enqueue:
+		if matchmaker == nil {
+			err = ErrMatchmakerStopped
+			break
+		}
+		// A match may take the player out of the lobby, so answer first
+		_, err = matchmaker.enqueueThen(client.PlayerID, func() {
+			pc.sendTo(client, protocol.Reply(msg, nil))
+		})
+		if err == nil {
+			return
+		}
+
+	case *protocol.Dequeue:
+		if matchmaker == nil {
+			err = ErrNotQueued
+			break
+		}
+		err = matchmaker.Dequeue(client.PlayerID)
+
+	case *protocol.CreateRoom, *protocol.JoinRoom, *protocol.ConfigureRoom, *protocol.LeaveRoom, *protocol.StartRoom:
+		if rooms == nil {
+			err = ErrNotInRoom
+			break
+		}
+		var started *Room
+		if started, err = rooms.handle(client.PlayerID, msg); started != nil {
+			// The player has left the lobby, the room answers them now
+			started.Reply(client.PlayerID, protocol.Reply(msg, nil))
+			return
+		}
+
+	default:
+		err = &protocol.DecodeError{
+			Code: protocol.CodeUnknownType,
+			Type: msg.MessageType(),
+			Err:  errors.New("not accepted in the lobby"),
+		}
+	}
+	pc.sendTo(client, protocol.Reply(msg, err))
+}
+
+// take removes the players of playerIDs from the lobby, without closing
+// their connections, and returns them. It takes either all or, when one of
+// them has left, none.
+func (pc *PlayerConnections) take(playerIDs []string) (map[string]*Client, bool) {
+	pc.mu.Lock()
+	clients := make(map[string]*Client, len(playerIDs))
+	for _, playerID := range playerIDs {
+		client, exists := pc.players[playerID]
+		if !exists {
+			pc.mu.Unlock()
+			return nil, false
+		}
+		clients[playerID] = client
+	}
+	for _, playerID := range playerIDs {
+		delete(pc.players, playerID)
+		delete(pc.seq, playerID)
+	}
+	pc.mu.Unlock()
+
+	go pc.broadcastPlayerList() // Takes the lock itself
+	return clients, true
+}
+
+// notify sends msg to playerID, if they are in the lobby
+func (pc *PlayerConnections) notify(playerID string, msg protocol.Message) {
+	if client, exists := pc.GetPlayerConnection(playerID); exists {
+		pc.sendTo(client, msg)
+	}
+}
+
+// sendTo sends msg to the player of client with the next number of their
+// lobby sequence
+func (pc *PlayerConnections) sendTo(client *Client, msg protocol.Message) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.players[client.PlayerID] != client {
+		return
+	}
+
+	pc.seq[client.PlayerID]++
+	jsonData, err := protocol.Encode(pc.seq[client.PlayerID], msg)
+	if err != nil {
+		fmt.Printf("Error marshaling message: %v\n", err)
+		return
+	}
+	if err := client.Send(jsonData); err != nil {
+		fmt.Printf("Error sending message to player %s: %v\n", client.PlayerID, err)
+	}
+}
+
+func (pc *PlayerConnections) GetPlayerList() []string {
+	fmt.Println("Entering GetPlayerList")
+	pc.mu.RLock()
+	fmt.Println("Acquired read lock in GetPlayerList")
+
+	// Create a copy of the players map
+	playersCopy := make(map[string]struct{})
+	for playerID := range pc.players {
+		playersCopy[playerID] = struct{}{}
+		fmt.Printf("Copied player: %s\n", playerID)
+	}
+	pc.mu.RUnlock()
+	fmt.Println("Released read lock in GetPlayerList")
+
+	playerList := make([]string, 0, len(playersCopy))
+	for playerID := range playersCopy {
+		playerList = append(playerList, playerID)
+		fmt.Printf("Added to list: %s\n", playerID)
+	}
+	fmt.Printf("GetPlayerList returning: %v\n", playerList)
+	return playerList
+}
+
+func (pc *PlayerConnections) broadcastPlayerList() {
+	fmt.Println("Starting to broadcast player list")
+	time.Sleep(100 * time.Millisecond) // Add a small delay
+	playerList := pc.GetPlayerList()
+	fmt.Printf("Player list: %v\n", playerList)
+
+	if err := pc.BroadcastMessage(protocol.PlayerList(playerList)); err != nil {
+		fmt.Printf("Error broadcasting message: %v\n", err)
+		return
+	}
+
+	fmt.Println("Player list broadcasted successfully")
+}
+
+func (pc *PlayerConnections) GetPlayerConnection(playerID string) (*Client, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+	conn, exists := pc.players[playerID]
+	fmt.Printf("%s Exists? %t \n", playerID, exists)
+	return conn, exists
+}
+
+// BroadcastMessage sends msg to all connected players, each with the next
+// number of their lobby sequence. A player who can't be sent to doesn't stop
+// the others from getting it; their errors are returned together.
+func (pc *PlayerConnections) BroadcastMessage(msg protocol.Message) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	var errs []error
+	for playerID, client := range pc.players {
+		pc.seq[playerID]++
+		jsonData, err := protocol.Encode(pc.seq[playerID], msg)
+		if err != nil {
+			return fmt.Errorf("error marshaling message: %v", err)
+		}
+		if err := client.Send(jsonData); err != nil {
+			fmt.Printf("Error broadcasting message to player %s: %v\n", playerID, err)
+			errs = append(errs, fmt.Errorf("player %s: %w", playerID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
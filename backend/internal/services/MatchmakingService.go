@@ -0,0 +1,317 @@
+package services
+
+import (
+	"dealer-backend/internal/protocol"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Errors returned by the Matchmaker
+var (
+	ErrNotInLobby        = protocol.NewError(protocol.CodeNotInLobby, "player is not connected to the lobby")
+	ErrAlreadyPlaying    = protocol.NewError(protocol.CodeAlreadyPlaying, "player already has a seat in a game")
+	ErrNotQueued         = protocol.NewError(protocol.CodeNotQueued, "player is not waiting for a game")
+	ErrMatchmakerStopped = protocol.NewError(protocol.CodeMatchmakerStopped, "matchmaker has stopped")
+)
+
+const (
+	matchmakerClock    = 1 * time.Second // How often waiting tickets are looked at for wider windows and bot fills
+	matchmakerCommands = 16              // Commands buffered for the matchmaker goroutine
+)
+
+// RatingWindow is how far apart in rating players who have just queued may
+// be to share a table. The window widens by RatingWindowGrowth for every
+// second a player waits, so an even match is preferred but nobody waits on
+// one forever.
+var (
+	RatingWindow       = 100.0
+	RatingWindowGrowth = 10.0
+)
+
+// Ticket is a player's place in the matchmaking queue
+type Ticket struct {
+	ID       string    `json:"ticketId"`
+	PlayerID string    `json:"playerId"`
+	Rating   float64   `json:"rating"` // When the player queued
+	Queued   time.Time `json:"queuedAt"`
+	Position int       `json:"position"` // 1 for the longest waiting player
+}
+
+// window is how far from the ticket's rating the player accepts others at
+func (t Ticket) window(now time.Time) float64 {
+	return RatingWindow + RatingWindowGrowth*now.Sub(t.Queued).Seconds()
+}
+
+// closeTo tells whether both players accept each other's rating
+func (t Ticket) closeTo(other Ticket, now time.Time) bool {
+	return math.Abs(t.Rating-other.Rating) <= math.Min(t.window(now), other.window(now))
+}
+
+// Matchmaker seats lobby players in games. Players queue up and are matched
+// in groups of TableSize close in rating, longest waiting first. Like a Room
+// it runs on its own goroutine and is only reached through commands.
+type Matchmaker struct {
+	lobby    *PlayerConnections
+	commands chan matchCommand
+	done     chan struct{}
+	stop     chan struct{}
+	queue    []Ticket // Oldest first
+	issued   int      // Tickets issued so far, to number them
+	clock    time.Duration
+}
+
+type matchCommandType string
+
+const (
+	commandEnqueue matchCommandType = "enqueue"
+	commandDequeue matchCommandType = "dequeue"
+)
+
+// matchCommand is a request to the matchmaker goroutine. err receives the
+// outcome and ticket the player's ticket for commandEnqueue. queued, when
+// set, runs once the player is queued but before they can be matched.
+type matchCommand struct {
+	kind     matchCommandType
+	playerID string
+	queued   func()
+	err      chan error
+	ticket   chan Ticket
+}
+
+// NewMatchmaker starts matching the players of lobby. Queue messages sent
+// from the lobby go to it from now on.
+func NewMatchmaker(lobby *PlayerConnections) *Matchmaker {
+	m := &Matchmaker{
+		lobby:    lobby,
+		commands: make(chan matchCommand, matchmakerCommands),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+		clock:    matchmakerClock,
+	}
+	lobby.setMatchmaker(m)
+	go m.run()
+	return m
+}
+
+// Stop ends the matchmaker. Tickets still waiting are dropped.
+func (m *Matchmaker) Stop() {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
+
+// Enqueue gives playerID a place in the queue, or returns the one they hold.
+// The player is told over the lobby connection too.
+func (m *Matchmaker) Enqueue(playerID string) (Ticket, error) {
+	return m.enqueueThen(playerID, nil)
+}
+
+// enqueueThen is Enqueue running queued before the player can be matched and
+// leave the lobby, so it can still answer them there
+func (m *Matchmaker) enqueueThen(playerID string, queued func()) (Ticket, error) {
+	reply := make(chan Ticket, 1)
+	if err := m.send(matchCommand{kind: commandEnqueue, playerID: playerID, queued: queued, ticket: reply}); err != nil {
+		return Ticket{}, err
+	}
+	return <-reply, nil
+}
+
+// Dequeue gives up playerID's place in the queue
+func (m *Matchmaker) Dequeue(playerID string) error {
+	return m.send(matchCommand{kind: commandDequeue, playerID: playerID})
+}
+
+// send hands cmd to the matchmaker goroutine and waits for the outcome
+func (m *Matchmaker) send(cmd matchCommand) error {
+	cmd.err = make(chan error, 1)
+	select {
+	case m.commands <- cmd:
+	case <-m.done:
+		return ErrMatchmakerStopped
+	}
+	select {
+	case err := <-cmd.err:
+		return err
+	case <-m.done:
+		return ErrMatchmakerStopped
+	}
+}
+
+func (m *Matchmaker) run() {
+	ticker := time.NewTicker(m.clock)
+	defer ticker.Stop()
+	defer close(m.done)
+
+	for {
+		select {
+		case cmd := <-m.commands:
+			cmd.err <- m.handle(cmd)
+		case now := <-ticker.C:
+			m.match(now)
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *Matchmaker) handle(cmd matchCommand) error {
+	switch cmd.kind {
+	case commandEnqueue:
+		ticket, err := m.enqueue(cmd.playerID, time.Now())
+		if err != nil {
+			return err
+		}
+		cmd.ticket <- ticket
+
+		m.lobby.notify(cmd.playerID, protocol.Queued{TicketID: ticket.ID, Position: ticket.Position})
+		if cmd.queued != nil {
+			cmd.queued()
+		}
+		m.match(time.Now())
+		return nil
+	case commandDequeue:
+		for i, ticket := range m.queue {
+			if ticket.PlayerID == cmd.playerID {
+				m.queue = append(m.queue[:i], m.queue[i+1:]...)
+				fmt.Println("Player", cmd.playerID, "left the queue")
+				return nil
+			}
+		}
+		return ErrNotQueued
+	default:
+		return fmt.Errorf("unknown matchmaker command %q", cmd.kind)
+	}
+}
+
+func (m *Matchmaker) enqueue(playerID string, now time.Time) (Ticket, error) {
+	for i, ticket := range m.queue {
+		if ticket.PlayerID == playerID {
+			ticket.Position = i + 1
+			return ticket, nil
+		}
+	}
+	if roomForPlayer(playerID) != nil {
+		return Ticket{}, ErrAlreadyPlaying
+	}
+	if _, connected := m.lobby.GetPlayerConnection(playerID); !connected {
+		return Ticket{}, ErrNotInLobby
+	}
+	if m.lobby.inPrivateRoom(playerID) {
+		return Ticket{}, ErrInPrivateRoom
+	}
+
+	m.issued++
+	ticket := Ticket{
+		ID:       fmt.Sprintf("ticket-%d", m.issued),
+		PlayerID: playerID,
+		Rating:   Ratings.Rating(playerID),
+		Queued:   now,
+		Position: len(m.queue) + 1,
+	}
+	m.queue = append(m.queue, ticket)
+	fmt.Println("Player", playerID, "queued with", ticket.ID)
+	return ticket, nil
+}
+
+// match starts a game for every group of TableSize players close enough in
+// rating. Once the longest waiting player has waited BotFillWait, they and
+// the players close to them start a game with bots.
+func (m *Matchmaker) match(now time.Time) {
+	for {
+		m.dropLeavers()
+		group := nextGroup(m.queue, now)
+		if group == nil {
+			return
+		}
+
+		playerIDs := make([]string, len(group))
+		for i, ticket := range group {
+			playerIDs[i] = ticket.PlayerID
+		}
+		clients, ok := m.lobby.take(playerIDs)
+		if !ok {
+			continue // Someone left in the meantime, look again
+		}
+		m.remove(group)
+		setup := DefaultGameSetup()
+		setup.Lobby = m.lobby
+		startGame(playerIDs, clients, setup)
+	}
+}
+
+// nextGroup returns the tickets to seat next, in queue order, or nil when no
+// game can start yet. Every player in turn, longest waiting first, is tried
+// as the core of a full table, so one player far from everyone else in
+// rating doesn't hold up the queue behind them.
+func nextGroup(queue []Ticket, now time.Time) []Ticket {
+	for i := range queue {
+		if group := groupAround(queue, i, now); len(group) == TableSize {
+			return group
+		}
+	}
+	if len(queue) > 0 && BotFillWait > 0 && now.Sub(queue[0].Queued) >= BotFillWait {
+		return groupAround(queue, 0, now)
+	}
+	return nil
+}
+
+// groupAround gathers up to TableSize tickets around queue[core]: the others
+// join in queue order as long as they are close to everyone gathered so far
+func groupAround(queue []Ticket, core int, now time.Time) []Ticket {
+	picked := []int{core}
+	for i, ticket := range queue {
+		if len(picked) == TableSize {
+			break
+		}
+		if i == core {
+			continue
+		}
+		near := true
+		for _, p := range picked {
+			near = near && ticket.closeTo(queue[p], now)
+		}
+		if near {
+			picked = append(picked, i)
+		}
+	}
+
+	sort.Ints(picked)
+	group := make([]Ticket, len(picked))
+	for i, p := range picked {
+		group[i] = queue[p]
+	}
+	return group
+}
+
+// remove takes the group's tickets out of the queue
+func (m *Matchmaker) remove(group []Ticket) {
+	seated := make(map[string]bool, len(group))
+	for _, ticket := range group {
+		seated[ticket.ID] = true
+	}
+	waiting := m.queue[:0]
+	for _, ticket := range m.queue {
+		if !seated[ticket.ID] {
+			waiting = append(waiting, ticket)
+		}
+	}
+	m.queue = waiting
+}
+
+// dropLeavers drops the tickets of players who have left the lobby
+func (m *Matchmaker) dropLeavers() {
+	waiting := m.queue[:0]
+	for _, ticket := range m.queue {
+		if _, connected := m.lobby.GetPlayerConnection(ticket.PlayerID); connected {
+			waiting = append(waiting, ticket)
+		} else {
+			fmt.Println("Dropping", ticket.ID, "of player", ticket.PlayerID, "who left the lobby")
+		}
+	}
+	m.queue = waiting
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startTestLobby runs a lobby and its matchmaker with one connection per
+// player, returning the test ends of the connections
+func startTestLobby(t *testing.T, playerIDs ...string) (*PlayerConnections, *Matchmaker, map[string]*websocket.Conn) {
+	t.Helper()
+	lobby := NewPlayerConnections()
+	m := NewMatchmaker(lobby)
+	conns := make(map[string]*websocket.Conn)
+	for _, playerID := range playerIDs {
+		server, client := connectTestPlayer(t, playerID)
+		lobby.AddPlayer(server)
+		conns[playerID] = client
+	}
+	t.Cleanup(func() {
+		m.Stop()
+		for _, playerID := range playerIDs {
+			if r := roomForPlayer(playerID); r != nil {
+				unregisterRoom(r)
+			}
+		}
+	})
+	return lobby, m, conns
+}
+
+func testPlayers(t *testing.T, n int) []string {
+	playerIDs := make([]string, n)
+	for i := range playerIDs {
+		playerIDs[i] = fmt.Sprintf("%s-%d", t.Name(), i+1)
+	}
+	return playerIDs
+}
+
+func TestPlayersAreMatchedFirstComeFirstServed(t *testing.T) {
+	wait := BotFillWait
+	t.Cleanup(func() { BotFillWait = wait }) // After the matchmaker has stopped
+	BotFillWait = 0
+
+	playerIDs := testPlayers(t, TableSize+1)
+	lobby, m, conns := startTestLobby(t, playerIDs...)
+	for i, playerID := range playerIDs {
+		ticket, err := m.Enqueue(playerID)
+		if err != nil {
+			t.Fatalf("enqueue %s: %v", playerID, err)
+		}
+		if i < TableSize && ticket.Position != i+1 {
+			t.Errorf("%s queued at %d, want %d", playerID, ticket.Position, i+1)
+		}
+	}
+
+	for i, playerID := range playerIDs[:TableSize] {
+		var found protocol.MatchFound
+		if err := json.Unmarshal(readUntil(t, conns[playerID], "match_found"), &found); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if found.Seat != i+1 || found.Players[i] != playerID {
+			t.Errorf("%s got seat %d of %v, want seat %d", playerID, found.Seat, found.Players, i+1)
+		}
+		if r := roomForPlayer(playerID); r == nil || r.ID != found.RoomID {
+			t.Errorf("%s is not seated in room %s", playerID, found.RoomID)
+		}
+	}
+
+	// The last player is still waiting in the lobby, alone
+	waiting := lobby.GetPlayerList()
+	if len(waiting) != 1 || waiting[0] != playerIDs[TableSize] {
+		t.Errorf("lobby holds %v, want only %s", waiting, playerIDs[TableSize])
+	}
+	if ticket, err := m.Enqueue(playerIDs[TableSize]); err != nil || ticket.Position != 1 {
+		t.Errorf("last player's ticket = %+v, %v, want position 1", ticket, err)
+	}
+	if _, err := m.Enqueue(playerIDs[0]); err != ErrAlreadyPlaying {
+		t.Errorf("enqueue while playing: %v, want ErrAlreadyPlaying", err)
+	}
+}
+
+func TestQueueingOverTheSocket(t *testing.T) {
+	playerIDs := testPlayers(t, 1)
+	_, _, conns := startTestLobby(t, playerIDs...)
+	conn := conns[playerIDs[0]]
+
+	send := func(raw string) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(raw)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	reply := func() protocol.Error {
+		for {
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("no reply: %v", err)
+			}
+			var envelope struct {
+				Type string
+				Data protocol.Error
+			}
+			json.Unmarshal(raw, &envelope)
+			if envelope.Type == "ok" || envelope.Type == "error" {
+				return envelope.Data
+			}
+		}
+	}
+
+	send(`{"type":"enqueue","requestId":"1"}`)
+	var queued protocol.Queued
+	if err := json.Unmarshal(readUntil(t, conn, "queued"), &queued); err != nil || queued.TicketID == "" {
+		t.Errorf("queued = %+v, %v", queued, err)
+	}
+	if got := reply(); got.RequestID != "1" || got.Code != "" {
+		t.Errorf("enqueue reply = %+v, want ok", got)
+	}
+
+	send(`{"type":"dequeue","requestId":"2"}`)
+	if got := reply(); got.RequestID != "2" || got.Code != "" {
+		t.Errorf("dequeue reply = %+v, want ok", got)
+	}
+	send(`{"type":"dequeue","requestId":"3"}`)
+	if got := reply(); got.RequestID != "3" || got.Code != protocol.CodeNotQueued {
+		t.Errorf("second dequeue reply = %+v, want %s", got, protocol.CodeNotQueued)
+	}
+}
+
+func TestLonePlayersGetBotsAfterWaiting(t *testing.T) {
+	wait := BotFillWait
+	t.Cleanup(func() { BotFillWait = wait }) // After the matchmaker has stopped
+	BotFillWait = 50 * time.Millisecond
+
+	playerIDs := testPlayers(t, 1)
+	_, m, conns := startTestLobby(t, playerIDs...)
+	if _, err := m.Enqueue(playerIDs[0]); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	var found protocol.MatchFound
+	if err := json.Unmarshal(readUntil(t, conns[playerIDs[0]], "match_found"), &found); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if found.Seat != 1 || len(found.Players) != TableSize {
+		t.Errorf("match = %+v, want seat 1 at a full table", found)
+	}
+}
+
+func TestRatingWindowWidensWhilePlayersWait(t *testing.T) {
+	start := time.Now()
+	ticket := func(id string, rating float64) Ticket {
+		return Ticket{ID: id, PlayerID: id, Rating: rating, Queued: start}
+	}
+	queue := []Ticket{ticket("strong", 2000)}
+	for i := 1; i < TableSize; i++ {
+		queue = append(queue, ticket(fmt.Sprintf("even-%d", i), 1500))
+	}
+	queue = append(queue, ticket("late", 1520))
+
+	// The even players don't wait on the strong player at the head
+	group := nextGroup(queue, start)
+	if len(group) != TableSize {
+		t.Fatalf("group = %v, want a full table", group)
+	}
+	for _, picked := range group {
+		if picked.ID == "strong" {
+			t.Errorf("strong player seated with %v right away", group)
+		}
+	}
+
+	// Alone they are not matched, until the window reaches them
+	alone := []Ticket{queue[0], queue[1], queue[2], queue[3]}
+	if group := nextGroup(alone, start); group != nil {
+		t.Errorf("group = %v, want no match across 500 points at once", group)
+	}
+	wait := time.Duration((500-RatingWindow)/RatingWindowGrowth) * time.Second
+	if group := nextGroup(alone, start.Add(wait)); len(group) != TableSize || group[0].ID != "strong" {
+		t.Errorf("group after %v = %v, want everyone in queue order", wait, group)
+	}
+}
+
+func TestPlayersQueueAgainAfterTheirGame(t *testing.T) {
+	playerIDs := testPlayers(t, 1)
+	player := playerIDs[0]
+	lobby, m, conns := startTestLobby(t, playerIDs...)
+	frontend := acknowledgeLikeTheFrontend(conns[player])
+
+	// A short game with bots
+	rooms := NewPrivateRooms(lobby)
+	if _, err := rooms.Create(player, protocol.RoomSettings{NumDeals: 1, Bots: true}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	r, err := rooms.Start(player)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		playUntilOver(t, r, player)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatal("game did not finish")
+	}
+
+	// Back in the lobby, on the same connection
+	if _, err := m.Enqueue(player); err != nil {
+		t.Fatalf("enqueue after the game: %v", err)
+	}
+	if err := frontend.send(`{"type":"dequeue","requestId":"again"}`); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case envelope := <-frontend.received:
+			var reply protocol.Error
+			json.Unmarshal(envelope.Data, &reply)
+			if reply.RequestID != "again" {
+				continue
+			}
+			if envelope.Type != "ok" {
+				t.Errorf("dequeue after the game = %+v, want ok", reply)
+			}
+			return
+		case <-timeout:
+			t.Fatal("lobby did not answer after the game")
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"crypto/rand"
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/protocol"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned for private rooms
+var (
+	ErrRoomNotFound  = protocol.NewError(protocol.CodeRoomNotFound, "no private room has this join code")
+	ErrRoomFull      = protocol.NewError(protocol.CodeRoomFull, "private room is full")
+	ErrNotInRoom     = protocol.NewError(protocol.CodeNotInRoom, "player is not in a private room")
+	ErrInPrivateRoom = protocol.NewError(protocol.CodeInPrivateRoom, "player is in a private room")
+	ErrNotHost       = protocol.NewError(protocol.CodeNotHost, "only the host of the room may do this")
+	ErrTooFewPlayers = protocol.NewError(protocol.CodeTooFewPlayers, "too few players to start without bots")
+	ErrRoomChanged   = protocol.NewError(protocol.CodeRoomChanged, "a player left the room while it was starting")
+)
+
+const (
+	joinCodeLength  = 6
+	joinCodeLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Nothing to mistake for 0, O, 1 or I
+	minTurnSeconds  = 10
+	maxTurnSeconds  = 300
+	maxNumDeals     = 20
+)
+
+// PrivateRoom is a table friends gather at by join code. Its players stay
+// in the lobby until the host starts the game.
+type PrivateRoom struct {
+	Code     string                `json:"code"`
+	Host     string                `json:"host"`
+	Players  []string              `json:"players"` // In the order they joined, which is the seating order
+	Settings protocol.RoomSettings `json:"settings"`
+}
+
+// message is the room as sent to its players
+func (p PrivateRoom) message() protocol.PrivateRoom {
+	return protocol.PrivateRoom{Code: p.Code, Host: p.Host, Players: p.Players, Seats: TableSize, Settings: p.Settings}
+}
+
+// setup is how the room's game is played
+func (p PrivateRoom) setup() GameSetup {
+	setup := DefaultGameSetup()
+	if !p.Settings.Bots {
+		setup.Seats = len(p.Players)
+	}
+	if p.Settings.NumDeals > 0 {
+		setup.NumDeals = p.Settings.NumDeals
+	}
+	if p.Settings.Variant != "" {
+		setup.Rules = models.RuleVariants[p.Settings.Variant]
+	}
+	if p.Settings.TurnSeconds > 0 {
+		setup.Options.TurnDuration = time.Duration(p.Settings.TurnSeconds) * time.Second
+	}
+	return setup
+}
+
+// validateSettings checks settings are within what a room can be given
+func validateSettings(settings protocol.RoomSettings) error {
+	switch {
+	case settings.TurnSeconds != 0 && (settings.TurnSeconds < minTurnSeconds || settings.TurnSeconds > maxTurnSeconds):
+		return protocol.NewError(protocol.CodeInvalidSettings, fmt.Sprintf("turn time must be between %d and %d seconds", minTurnSeconds, maxTurnSeconds))
+	case settings.NumDeals < 0 || settings.NumDeals > maxNumDeals:
+		return protocol.NewError(protocol.CodeInvalidSettings, fmt.Sprintf("number of deals must be between 0 (the default) and %d", maxNumDeals))
+	}
+	if _, known := models.RuleVariants[settings.Variant]; settings.Variant != "" && !known {
+		return protocol.NewError(protocol.CodeInvalidSettings, fmt.Sprintf("unknown rule variant %q", settings.Variant))
+	}
+	return nil
+}
+
+// NormalizeJoinCode returns code as rooms are keyed by, so codes typed in
+// lower case or with spaces around them still match
+func NormalizeJoinCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PrivateRooms holds the private rooms of a lobby, safe for use from any
+// goroutine. Everyone in a room hears of every change to it.
+type PrivateRooms struct {
+	lobby    *PlayerConnections
+	mu       sync.Mutex
+	byCode   map[string]*PrivateRoom
+	byPlayer map[string]*PrivateRoom
+	entering map[string]bool // Players on their way into a room, held against the matchmaker
+}
+
+// NewPrivateRooms returns the private rooms of lobby. Room messages sent
+// from the lobby go to them from now on.
+func NewPrivateRooms(lobby *PlayerConnections) *PrivateRooms {
+	pr := &PrivateRooms{
+		lobby:    lobby,
+		byCode:   make(map[string]*PrivateRoom),
+		byPlayer: make(map[string]*PrivateRoom),
+		entering: make(map[string]bool),
+	}
+	lobby.setPrivateRooms(pr)
+	return pr
+}
+
+// Create opens a room hosted by playerID, who leaves the matchmaking queue
+func (pr *PrivateRooms) Create(playerID string, settings protocol.RoomSettings) (PrivateRoom, error) {
+	if err := validateSettings(settings); err != nil {
+		return PrivateRoom{}, err
+	}
+	if err := pr.enter(playerID); err != nil {
+		return PrivateRoom{}, err
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	delete(pr.entering, playerID)
+	code, err := pr.newCode()
+	if err != nil {
+		return PrivateRoom{}, err
+	}
+	room := &PrivateRoom{Code: code, Host: playerID, Players: []string{playerID}, Settings: settings}
+	pr.byCode[code] = room
+	pr.byPlayer[playerID] = room
+	fmt.Println("Player", playerID, "opened private room", code)
+
+	pr.announce(room)
+	return room.copy(), nil
+}
+
+// Join seats playerID in the room with join code, after the players already
+// there. They leave the matchmaking queue.
+func (pr *PrivateRooms) Join(playerID, code string) (PrivateRoom, error) {
+	code = NormalizeJoinCode(code)
+	if err := pr.enter(playerID); err != nil {
+		return PrivateRoom{}, err
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	delete(pr.entering, playerID)
+	room, ok := pr.byCode[code]
+	if !ok {
+		return PrivateRoom{}, ErrRoomNotFound
+	}
+	if len(room.Players) >= TableSize {
+		return PrivateRoom{}, ErrRoomFull
+	}
+	room.Players = append(room.Players, playerID)
+	pr.byPlayer[playerID] = room
+	fmt.Println("Player", playerID, "joined private room", code)
+
+	pr.announce(room)
+	return room.copy(), nil
+}
+
+// Configure replaces the settings of the room hosted by playerID
+func (pr *PrivateRooms) Configure(playerID string, settings protocol.RoomSettings) (PrivateRoom, error) {
+	if err := validateSettings(settings); err != nil {
+		return PrivateRoom{}, err
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	room, err := pr.hostedBy(playerID)
+	if err != nil {
+		return PrivateRoom{}, err
+	}
+	room.Settings = settings
+
+	pr.announce(room)
+	return room.copy(), nil
+}
+
+// Leave takes playerID out of their room. A room left by its host passes to
+// the player who joined next; an empty room is closed.
+func (pr *PrivateRooms) Leave(playerID string) error {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	room, ok := pr.byPlayer[playerID]
+	if !ok {
+		return ErrNotInRoom
+	}
+	delete(pr.byPlayer, playerID)
+	for i, id := range room.Players {
+		if id == playerID {
+			room.Players = append(room.Players[:i], room.Players[i+1:]...)
+			break
+		}
+	}
+	fmt.Println("Player", playerID, "left private room", room.Code)
+
+	if len(room.Players) == 0 {
+		delete(pr.byCode, room.Code)
+		fmt.Println("Closed private room", room.Code)
+		return nil
+	}
+	if room.Host == playerID {
+		room.Host = room.Players[0]
+	}
+	pr.announce(room)
+	return nil
+}
+
+// Start starts the game of the room hosted by playerID and closes the room.
+// Seats left over go to bots if the room allows them.
+func (pr *PrivateRooms) Start(playerID string) (*Room, error) {
+	pr.mu.Lock()
+	room, err := pr.hostedBy(playerID)
+	if err != nil {
+		pr.mu.Unlock()
+		return nil, err
+	}
+	if !room.Settings.Bots && len(room.Players) < models.MinPlayers {
+		pr.mu.Unlock()
+		return nil, ErrTooFewPlayers
+	}
+	clients, ok := pr.lobby.take(room.Players)
+	if !ok {
+		pr.mu.Unlock()
+		return nil, ErrRoomChanged // They are on their way out of the room
+	}
+	delete(pr.byCode, room.Code)
+	for _, id := range room.Players {
+		delete(pr.byPlayer, id)
+	}
+	pr.mu.Unlock()
+
+	fmt.Println("Starting private room", room.Code)
+	setup := room.setup()
+	setup.Lobby = pr.lobby
+	return startGame(room.Players, clients, setup), nil
+}
+
+// handle carries out a room message of playerID. It returns the room of the
+// game when the message started one.
+func (pr *PrivateRooms) handle(playerID string, msg protocol.ClientMessage) (*Room, error) {
+	var err error
+	switch m := msg.(type) {
+	case *protocol.CreateRoom:
+		_, err = pr.Create(playerID, m.Settings)
+	case *protocol.JoinRoom:
+		_, err = pr.Join(playerID, m.Code)
+	case *protocol.ConfigureRoom:
+		_, err = pr.Configure(playerID, m.Settings)
+	case *protocol.LeaveRoom:
+		err = pr.Leave(playerID)
+	case *protocol.StartRoom:
+		return pr.Start(playerID)
+	default:
+		err = fmt.Errorf("not a private room message: %s", msg.MessageType())
+	}
+	return nil, err
+}
+
+// RoomOf returns the room playerID is in
+func (pr *PrivateRooms) RoomOf(playerID string) (PrivateRoom, bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	room, ok := pr.byPlayer[playerID]
+	if !ok {
+		return PrivateRoom{}, false
+	}
+	return room.copy(), true
+}
+
+// holds tells whether playerID is in a room or on their way into one
+func (pr *PrivateRooms) holds(playerID string) bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.byPlayer[playerID] != nil || pr.entering[playerID]
+}
+
+// enter holds playerID against the matchmaker and takes them out of its
+// queue. The caller takes the lock next and clears pr.entering once the
+// player is in the room or turned away.
+//
+// The player is held first so the matchmaker, which asks the rooms about
+// players too, can't queue them again between the dequeue and the lock. The
+// dequeue itself runs without the lock for the same reason.
+func (pr *PrivateRooms) enter(playerID string) error {
+	pr.mu.Lock()
+	if pr.byPlayer[playerID] != nil || pr.entering[playerID] {
+		pr.mu.Unlock()
+		return ErrInPrivateRoom
+	}
+	pr.entering[playerID] = true
+	pr.mu.Unlock()
+
+	if err := pr.canEnter(playerID); err != nil {
+		pr.mu.Lock()
+		delete(pr.entering, playerID)
+		pr.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// canEnter takes playerID out of the matchmaking queue, then checks they
+// weren't seated in a game before that and are still in the lobby
+func (pr *PrivateRooms) canEnter(playerID string) error {
+	pr.lobby.mu.RLock()
+	matchmaker := pr.lobby.matchmaker
+	pr.lobby.mu.RUnlock()
+	if matchmaker != nil {
+		switch err := matchmaker.Dequeue(playerID); err {
+		case nil, ErrNotQueued, ErrMatchmakerStopped:
+		default:
+			return err
+		}
+	}
+
+	if roomForPlayer(playerID) != nil {
+		return ErrAlreadyPlaying
+	}
+	if _, connected := pr.lobby.GetPlayerConnection(playerID); !connected {
+		return ErrNotInLobby
+	}
+	return nil
+}
+
+// hostedBy returns the room playerID hosts, with the lock held
+func (pr *PrivateRooms) hostedBy(playerID string) (*PrivateRoom, error) {
+	room, ok := pr.byPlayer[playerID]
+	if !ok {
+		return nil, ErrNotInRoom
+	}
+	if room.Host != playerID {
+		return nil, ErrNotHost
+	}
+	return room, nil
+}
+
+// announce sends the room to everyone in it, with the lock held
+func (pr *PrivateRooms) announce(room *PrivateRoom) {
+	msg := room.copy().message()
+	for _, playerID := range room.Players {
+		pr.lobby.notify(playerID, msg)
+	}
+}
+
+// newCode returns a join code no open room has, with the lock held
+func (pr *PrivateRooms) newCode() (string, error) {
+	for {
+		code := make([]byte, joinCodeLength)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(joinCodeLetters))))
+			if err != nil {
+				return "", fmt.Errorf("drawing a join code: %w", err)
+			}
+			code[i] = joinCodeLetters[n.Int64()]
+		}
+		if _, taken := pr.byCode[string(code)]; !taken {
+			return string(code), nil
+		}
+	}
+}
+
+// copy returns the room with a player list of its own, safe to hand out
+func (p *PrivateRoom) copy() PrivateRoom {
+	copied := *p
+	copied.Players = append([]string(nil), p.Players...)
+	return copied
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestPrivateRoomsStartWithTheirSettings(t *testing.T) {
+	playerIDs := testPlayers(t, 2)
+	host, friend := playerIDs[0], playerIDs[1]
+	lobby, _, conns := startTestLobby(t, playerIDs...)
+	rooms := NewPrivateRooms(lobby)
+
+	settings := protocol.RoomSettings{TurnSeconds: 20, NumDeals: 2, Variant: "casual", Bots: true}
+	created, err := rooms.Create(host, settings)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(created.Code) != joinCodeLength {
+		t.Errorf("join code %q, want %d characters", created.Code, joinCodeLength)
+	}
+	if _, err := rooms.Join(friend, strings.ToLower(created.Code)); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+
+	var state protocol.PrivateRoom
+	for len(state.Players) < 2 {
+		if err := json.Unmarshal(readUntil(t, conns[friend], "private_room"), &state); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+	}
+	if state.Host != host || state.Players[1] != friend || state.Settings != settings {
+		t.Errorf("room = %+v, want %s hosting %s with %+v", state, host, friend, settings)
+	}
+
+	// Only the host runs the room
+	if _, err := rooms.Configure(friend, protocol.RoomSettings{}); !errors.Is(err, ErrNotHost) {
+		t.Errorf("configure by a guest: %v, want ErrNotHost", err)
+	}
+	if _, err := rooms.Start(friend); !errors.Is(err, ErrNotHost) {
+		t.Errorf("start by a guest: %v, want ErrNotHost", err)
+	}
+	if _, err := rooms.Configure(host, protocol.RoomSettings{Variant: "blitz"}); protocol.CodeFor(err) != protocol.CodeInvalidSettings {
+		t.Errorf("unknown variant: %v, want %s", err, protocol.CodeInvalidSettings)
+	}
+	if _, err := rooms.Configure(host, protocol.RoomSettings{NumDeals: maxNumDeals + 1}); protocol.CodeFor(err) != protocol.CodeInvalidSettings {
+		t.Errorf("too many deals: %v, want %s", err, protocol.CodeInvalidSettings)
+	}
+	if NormalizeJoinCode(" "+strings.ToLower(created.Code)+"\n") != created.Code {
+		t.Errorf("join code %q does not survive normalizing", created.Code)
+	}
+
+	r, err := rooms.Start(host)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if len(r.players) != TableSize || r.players[0] != host || r.players[1] != friend {
+		t.Errorf("seated %v, want %s and %s joined by bots", r.players, host, friend)
+	}
+	if r.options.TurnDuration != 20*time.Second {
+		t.Errorf("turn time %v, want 20s", r.options.TurnDuration)
+	}
+	view, err := r.Snapshot(host)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if view.State.Rules.Variant != "casual" || view.State.NumDeals != 2 {
+		t.Errorf("game plays %s rules over %d deals, want casual over 2", view.State.Rules.Variant, view.State.NumDeals)
+	}
+	for _, playerID := range playerIDs {
+		readUntil(t, conns[playerID], "match_found")
+	}
+	if _, open := rooms.RoomOf(host); open {
+		t.Error("room still open after its game started")
+	}
+}
+
+func TestPrivateRoomsOverTheSocket(t *testing.T) {
+	playerIDs := testPlayers(t, 2)
+	host, friend := playerIDs[0], playerIDs[1]
+	lobby, _, conns := startTestLobby(t, playerIDs...)
+	NewPrivateRooms(lobby)
+
+	send := func(playerID, raw string) {
+		if err := conns[playerID].WriteMessage(websocket.TextMessage, []byte(raw)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	reply := func(playerID string) protocol.Error {
+		for {
+			var envelope struct {
+				Type string
+				Data protocol.Error
+			}
+			conns[playerID].SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, raw, err := conns[playerID].ReadMessage()
+			if err != nil {
+				t.Fatalf("no reply for %s: %v", playerID, err)
+			}
+			json.Unmarshal(raw, &envelope)
+			if envelope.Type == "ok" || envelope.Type == "error" {
+				return envelope.Data
+			}
+		}
+	}
+	room := func(playerID string) protocol.PrivateRoom {
+		var state protocol.PrivateRoom
+		if err := json.Unmarshal(readUntil(t, conns[playerID], "private_room"), &state); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return state
+	}
+
+	send(host, `{"type":"createroom","requestId":"1"}`)
+	code := room(host).Code
+	if got := reply(host); got.RequestID != "1" || got.Code != "" {
+		t.Fatalf("createroom reply = %+v, want ok", got)
+	}
+
+	send(friend, `{"type":"joinroom","requestId":"2","code":"NOPE"}`)
+	if got := reply(friend); got.Code != protocol.CodeRoomNotFound {
+		t.Errorf("join with a wrong code = %+v, want %s", got, protocol.CodeRoomNotFound)
+	}
+	send(friend, `{"type":"joinroom","requestId":"3","code":"`+code+`"}`)
+	if got := room(friend); len(got.Players) != 2 {
+		t.Errorf("room after joining = %+v", got)
+	}
+	if got := reply(friend); got.RequestID != "3" || got.Code != "" {
+		t.Errorf("joinroom reply = %+v, want ok", got)
+	}
+	send(friend, `{"type":"enqueue","requestId":"4"}`)
+	if got := reply(friend); got.Code != protocol.CodeInPrivateRoom {
+		t.Errorf("enqueue from a private room = %+v, want %s", got, protocol.CodeInPrivateRoom)
+	}
+
+	// The host leaves, so the room passes to the friend, who can't play alone
+	send(host, `{"type":"leaveroom","requestId":"5"}`)
+	if got := room(friend); got.Host != friend || len(got.Players) != 1 {
+		t.Errorf("room after the host left = %+v, want %s hosting alone", got, friend)
+	}
+	send(friend, `{"type":"startroom","requestId":"6"}`)
+	if got := reply(friend); got.Code != protocol.CodeTooFewPlayers {
+		t.Errorf("start alone without bots = %+v, want %s", got, protocol.CodeTooFewPlayers)
+	}
+}
+
+func TestPlayersEnteringARoomAreNotQueued(t *testing.T) {
+	playerID := testPlayers(t, 1)[0]
+	lobby, m, _ := startTestLobby(t, playerID)
+	rooms := NewPrivateRooms(lobby)
+
+	for round := 0; round < 1000; round++ {
+		// The player keeps asking for a game while they open a room
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					m.Enqueue(playerID)
+				}
+			}
+		}()
+		_, createErr := rooms.Create(playerID, protocol.RoomSettings{})
+		close(stop)
+		wg.Wait()
+
+		if createErr != nil {
+			t.Fatalf("round %d: create: %v", round, createErr)
+		}
+		if err := m.Dequeue(playerID); !errors.Is(err, ErrNotQueued) {
+			t.Fatalf("round %d: player in a private room was still queued (dequeue: %v)", round, err)
+		}
+		if err := rooms.Leave(playerID); err != nil {
+			t.Fatalf("round %d: leave: %v", round, err)
+		}
+	}
+}
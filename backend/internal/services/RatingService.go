@@ -0,0 +1,189 @@
+package services
+
+import (
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/rating"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// BotRatings is what a bot of each level counts as when rating the players
+// it sat with. Bots have no rating of their own that changes.
+var BotRatings = map[bot.Level]float64{
+	bot.Easy:   1200,
+	bot.Medium: rating.Initial,
+	bot.Hard:   1800,
+}
+
+// Ratings holds the rating of every player who has finished a game
+var Ratings = NewRatingStore()
+
+// RatingChange is what one finished game did to a player's rating
+type RatingChange struct {
+	GameID  string    `json:"gameId"`
+	Place   int       `json:"place"`   // 1 for the winner
+	Players int       `json:"players"` // Players at the table, bots included
+	Before  float64   `json:"before"`
+	After   float64   `json:"after"`
+	At      time.Time `json:"at"`
+}
+
+// PlayerRating is a player's current rating and how it came about
+type PlayerRating struct {
+	PlayerID string         `json:"playerId"`
+	Rating   float64        `json:"rating"`
+	Games    int            `json:"games"`
+	History  []RatingChange `json:"history"` // Oldest first
+}
+
+// RatingStore keeps the players' ratings. It is shared by the rooms, which
+// record results, and the matchmaker and handlers, which read them.
+type RatingStore struct {
+	mu      sync.RWMutex
+	players map[string]*PlayerRating
+	file    string // Where the ratings are kept between restarts, if anywhere
+}
+
+// NewRatingStore returns a store where everyone has the initial rating
+func NewRatingStore() *RatingStore {
+	return &RatingStore{players: make(map[string]*PlayerRating)}
+}
+
+// Rating returns playerID's current rating
+func (s *RatingStore) Rating(playerID string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if player, ok := s.players[playerID]; ok {
+		return player.Rating
+	}
+	return rating.Initial
+}
+
+// Get returns a copy of playerID's rating and history. A player who has not
+// finished a game has the initial rating and no history.
+func (s *RatingStore) Get(playerID string) PlayerRating {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	player, ok := s.players[playerID]
+	if !ok {
+		return PlayerRating{PlayerID: playerID, Rating: rating.Initial, History: []RatingChange{}}
+	}
+	copied := *player
+	copied.History = append([]RatingChange(nil), player.History...)
+	return copied
+}
+
+// Record rates the players of a finished game from its standings. Seats in
+// fixed play at the rating given and are not rated themselves, which is how
+// bots take part. It returns the change of every rated player by their ID.
+func (s *RatingStore) Record(gameID string, standings []models.Standing, fixed map[string]float64) map[string]RatingChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]rating.Result, len(standings))
+	rated := 0
+	for i, standing := range standings {
+		r, ok := fixed[standing.PlayerID]
+		if !ok {
+			r = rating.Initial
+			if player, known := s.players[standing.PlayerID]; known {
+				r = player.Rating
+			}
+			rated++
+		}
+		results[i] = rating.Result{Rating: r, Place: standing.Rank}
+	}
+	if rated == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	changes := make(map[string]RatingChange, rated)
+	for i, after := range rating.Update(results) {
+		playerID := standings[i].PlayerID
+		if _, ok := fixed[playerID]; ok {
+			continue
+		}
+		change := RatingChange{
+			GameID:  gameID,
+			Place:   standings[i].Rank,
+			Players: len(standings),
+			Before:  results[i].Rating,
+			After:   after,
+			At:      now,
+		}
+		player, ok := s.players[playerID]
+		if !ok {
+			player = &PlayerRating{PlayerID: playerID}
+			s.players[playerID] = player
+		}
+		player.Rating = after
+		player.Games++
+		player.History = append(player.History, change)
+		changes[playerID] = change
+	}
+	s.save()
+	return changes
+}
+
+// Load reads the ratings kept in file and keeps them there from now on. A
+// missing file is a fresh start, not an error; an empty name keeps the
+// ratings in memory only.
+func (s *RatingStore) Load(file string) error {
+	players := make(map[string]*PlayerRating)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &players); err != nil {
+				return fmt.Errorf("reading ratings from %s: %w", file, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players = players
+	s.file = file
+	return nil
+}
+
+// save writes the ratings to the store's file, with the lock held
+func (s *RatingStore) save() {
+	if s.file == "" {
+		return
+	}
+	data, err := json.MarshalIndent(s.players, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding ratings: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(s.file, data, 0o644); err != nil {
+		log.Printf("Error writing ratings to %s: %v\n", s.file, err)
+	}
+}
+
+// rate records the final standings of the room's game in the room's rating
+// store, if it has one. Bots play at the rating of their level.
+func (r *Room) rate() {
+	if r.options.Ratings == nil {
+		return
+	}
+	fixed := make(map[string]float64, len(r.bots))
+	for playerID, b := range r.bots {
+		fixed[playerID] = BotRatings[b.Level]
+	}
+
+	for playerID, change := range r.options.Ratings.Record(r.ID, engine.Standings(&r.game.State), fixed) {
+		fmt.Printf("Player %s placed %d of %d in game %s, rating %.0f -> %.0f\n", playerID, change.Place, change.Players, r.ID, change.Before, change.After)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/rating"
+	"path/filepath"
+	"testing"
+)
+
+func TestRatingsFollowFinishedGames(t *testing.T) {
+	store := NewRatingStore()
+	fixed := map[string]float64{"bot-1": BotRatings[bot.Hard], "bot-2": BotRatings[bot.Easy]}
+
+	store.Record("game-1", []models.Standing{
+		{PlayerID: "alice", Rank: 1},
+		{PlayerID: "bot-1", Rank: 2},
+		{PlayerID: "bob", Rank: 3},
+		{PlayerID: "bot-2", Rank: 4},
+	}, fixed)
+	store.Record("game-2", []models.Standing{
+		{PlayerID: "bob", Rank: 1},
+		{PlayerID: "alice", Rank: 1},
+	}, nil)
+
+	alice := store.Get("alice")
+	if alice.Games != 2 || len(alice.History) != 2 {
+		t.Fatalf("alice = %+v, want two games in her history", alice)
+	}
+	first, second := alice.History[0], alice.History[1]
+	if first.GameID != "game-1" || first.Place != 1 || first.Players != 4 || first.Before != rating.Initial || first.After <= first.Before {
+		t.Errorf("first game = %+v, want a win from the initial rating", first)
+	}
+	if second.Before != first.After || second.After != alice.Rating {
+		t.Errorf("second game = %+v after %+v, want it to carry on from the first", second, first)
+	}
+	if bob := store.Get("bob"); bob.Rating != store.Rating("bob") || bob.Games != 2 {
+		t.Errorf("bob = %+v", bob)
+	}
+
+	// Bots only lend their rating
+	if bot := store.Get("bot-1"); bot.Games != 0 || bot.Rating != rating.Initial {
+		t.Errorf("bot was rated: %+v", bot)
+	}
+	if changes := store.Record("game-3", []models.Standing{{PlayerID: "bot-1", Rank: 1}, {PlayerID: "bot-2", Rank: 2}}, fixed); changes != nil {
+		t.Errorf("game of bots changed %v", changes)
+	}
+}
+
+func TestRatingsSurviveARestart(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ratings.json")
+
+	store := NewRatingStore()
+	if err := store.Load(file); err != nil {
+		t.Fatalf("load without a file: %v", err)
+	}
+	store.Record("game-1", []models.Standing{{PlayerID: "alice", Rank: 2}, {PlayerID: "bob", Rank: 1}}, nil)
+
+	restarted := NewRatingStore()
+	if err := restarted.Load(file); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got, want := restarted.Get("alice"), store.Get("alice"); got.Rating != want.Rating || len(got.History) != 1 {
+		t.Errorf("alice after restart = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/protocol"
+	"fmt"
+	"time"
+)
+
+// handleRedeal throws the hands in at playerID's request, when their hand
+// allows it and nobody has bid yet
+func (r *Room) handleRedeal(playerID string) error {
+	events, err := r.apply(engine.Redeal{PlayerID: playerID})
+	if err != nil {
+		fmt.Printf("Rejected redeal request from player %s: %v\n", playerID, err)
+		return err
+	}
+	fmt.Println("Player", playerID, "asked for a redeal")
+	r.afterEvents(events)
+	return nil
+}
+
+// openRedealWindow holds the bidding for a moment after the deal when a
+// player may ask for a redeal, and tells them so. Bots never ask.
+func (r *Room) openRedealWindow(now time.Time) {
+	r.redealUntil = time.Time{}
+	if r.options.RedealWindow <= 0 {
+		return
+	}
+	for _, player := range r.game.State.Players() {
+		if _, isBot := r.bots[player.ID]; isBot || r.absent(player.ID) {
+			continue
+		}
+		condition, misdealt := engine.Misdealt(&r.game.State, player)
+		if !misdealt {
+			continue
+		}
+		r.redealUntil = now.Add(r.options.RedealWindow)
+		r.sendTo(player.ID, protocol.RedealOffer{
+			Reason:  engine.MisdealReason(condition),
+			Seconds: int(r.options.RedealWindow / time.Second),
+		})
+	}
+}
+
+// redealOpen reports whether bidding is held for a possible redeal request.
+// The window closes for good with the first bid.
+func (r *Room) redealOpen(now time.Time) bool {
+	if len(r.game.State.Bids) > 0 {
+		r.redealUntil = time.Time{}
+	}
+	return now.Before(r.redealUntil)
+}
+
+// announceMisdeal tells everyone why the hands were thrown in
+func (r *Room) announceMisdeal(misdeal engine.Misdeal) {
+	fmt.Printf("Misdeal in game %s: %s has %s\n", r.ID, misdeal.PlayerID, misdeal.Reason)
+	r.notifyAll(protocol.Misdeal{PlayerID: misdeal.PlayerID, Reason: misdeal.Reason, Requested: misdeal.Requested})
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrSeatForfeited is returned when a player comes back after their grace
+// period ran out
+var ErrSeatForfeited = protocol.NewError(protocol.CodeSeatForfeited, "seat was forfeited")
+
+// maxMissedMessages caps what is kept for a disconnected player, the resume
+// snapshot covers anything older
+const maxMissedMessages = 256
+
+// transientMessages are only useful while they are fresh and are not kept
+// for disconnected players
+var transientMessages = map[string]bool{
+	"healthstate": true,
+	"redealoffer": true,
+	"updatebid":   true,
+	"error":       true,
+}
+
+// ResumePlayer rebinds a reconnecting player to the seat they hold in an
+// active room. It reports false when the player has no seat to go back to.
+func ResumePlayer(conn *Client) bool {
+	playerID := conn.PlayerID
+	r := roomForPlayer(playerID)
+	if r == nil {
+		return false
+	}
+
+	if err := r.Join(playerID, conn); err != nil {
+		log.Printf("Player %s could not resume game %s: %v\n", playerID, r.ID, err)
+		return false
+	}
+
+	// Read the new connection like the original ones
+	StartMessageRouter(r.ID, map[string]*Client{playerID: conn})
+	fmt.Println("Player", playerID, "resumed game", r.ID)
+	return true
+}
+
+// handleJoin swaps conn in for playerID and sends them a snapshot together
+// with the messages they missed while away
+func (r *Room) handleJoin(playerID string, conn *Client) error {
+	if r.forfeited[playerID] {
+		return ErrSeatForfeited
+	}
+
+	if old, exists := r.connections[playerID]; exists && old != conn {
+		old.Close()
+	}
+	r.connections[playerID] = conn
+	r.markPresent(playerID)
+
+	missed := r.missed[playerID]
+	delete(r.missed, playerID)
+	if missed == nil {
+		missed = []json.RawMessage{}
+	}
+
+	r.sendTo(playerID, protocol.Resume{Snapshot: r.game.ViewFor(playerID), Missed: missed})
+	if _, away := r.disconnected[playerID]; away {
+		r.presenceChanged(playerID, true)
+	}
+	return nil
+}
+
+// handleLeave unbinds conn from playerID's seat and starts the grace period.
+// A connection that has already been replaced is ignored.
+func (r *Room) handleLeave(playerID string, conn *Client) {
+	if current, exists := r.connections[playerID]; !exists || current != conn {
+		return
+	}
+
+	delete(r.connections, playerID)
+	r.presenceChanged(playerID, false)
+}
+
+// presenceChanged reacts to playerID's connection being lost or restored.
+// While disconnected the player holds up no acknowledgments, keeps the
+// messages they miss and has the grace period to come back.
+func (r *Room) presenceChanged(playerID string, connected bool) {
+	if connected {
+		delete(r.disconnected, playerID)
+		fmt.Println("Player", playerID, "reconnected to game", r.ID)
+		r.notifyAll(protocol.PlayerReconnected{PlayerID: playerID})
+		return
+	}
+
+	delete(r.pendingAcks, playerID)
+	r.disconnected[playerID] = time.Now()
+	fmt.Println("Player", playerID, "disconnected from game", r.ID)
+	r.notifyAll(protocol.PlayerDisconnected{
+		PlayerID: playerID,
+		Seconds:  int(r.options.GracePeriod / time.Second),
+	})
+}
+
+// tickPresence forfeits the seats of players whose grace period has run out
+func (r *Room) tickPresence(now time.Time) {
+	for playerID, since := range r.disconnected {
+		if now.Sub(since) < r.options.GracePeriod {
+			continue
+		}
+
+		delete(r.disconnected, playerID)
+		delete(r.missed, playerID)
+		r.forfeited[playerID] = true
+		fmt.Println("Player", playerID, "forfeited their seat in game", r.ID)
+
+		r.notifyAll(protocol.SeatForfeited{PlayerID: playerID})
+	}
+}
+
+// keepMissed stores a message for a disconnected player
+func (r *Room) keepMissed(playerID string, message []byte) {
+	missed := append(r.missed[playerID], json.RawMessage(message))
+	if len(missed) > maxMissedMessages {
+		missed = missed[len(missed)-maxMissedMessages:]
+	}
+	r.missed[playerID] = missed
+}
@@ -1,370 +1,553 @@
 package services
 
 import (
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/engine"
 	"dealer-backend/internal/models"
+	"dealer-backend/internal/protocol"
 	"encoding/json"
 	"fmt"
-
 	"log"
-	// "sync"
+	"sync"
 	"time"
+)
+
+// ErrRoomClosed is returned by Room methods once the game has finished
+var ErrRoomClosed = protocol.NewError(protocol.CodeRoomClosed, "room is closed")
+
+// Room owns a running game. Its state and connections are only touched by
+// the room's own goroutine; everything else talks to it through commands.
+type Room struct {
+	ID       string
+	players  []string // Seated player IDs, fixed for the life of the room
+	commands chan roomCommand
+	done     chan struct{}
+
+	// Owned by the run goroutine
+	game         *models.Game
+	log          *engine.GameLog
+	connections  map[string]*Client
+	pendingAcks  map[string]int // Broadcasts each player has yet to acknowledge
+	ackDeadline  time.Time
+	bidDeadline  time.Time
+	nextReminder time.Time
+	redealUntil  time.Time                    // Bidding is held until then for a redeal request
+	disconnected map[string]time.Time         // Seats whose player dropped, and since when
+	forfeited    map[string]bool              // Seats whose player did not come back in time
+	missed       map[string][]json.RawMessage // Messages kept for disconnected players
+	seq          map[string]uint64            // Messages sent to each player in this game
+	bots         map[string]*bot.Bot          // Seats played by the server
+	thinking     bool                         // A bot is choosing its card off the room goroutine
+	lobby        *PlayerConnections           // Where the players go once the game is over, if anywhere
+	options      RoomOptions
+	clock        time.Duration   // Period of the room clock
+	turnLeft     int             // Ticks left on the current player's turn timer
+	timeouts     map[string]int  // Consecutive timeouts per player
+	away         map[string]bool // Players whose turns are auto-played until they come back
+}
 
-	"github.com/gorilla/websocket"
+type commandType string
+
+const (
+	commandPlay     commandType = "play"
+	commandBid      commandType = "bid"
+	commandRedeal   commandType = "redeal"
+	commandAck      commandType = "ack"
+	commandJoin     commandType = "join"
+	commandLeave    commandType = "leave"
+	commandSnapshot commandType = "snapshot"
+	commandLog      commandType = "log"
+	commandBack     commandType = "back"
+	commandReply    commandType = "reply"
+	commandBotPlay  commandType = "botplay"
 )
 
-var gameRooms = make(map[string]*models.Game)
+// roomCommand is a request to the room goroutine. err receives the outcome,
+// snapshot the view for commandSnapshot and entries the log for commandLog.
+// botErr is why a bot could not choose a card, for commandBotPlay.
+type roomCommand struct {
+	kind     commandType
+	playerID string
+	card     models.Card
+	botErr   error
+	bid      int
+	conn     *Client
+	reply    protocol.Message
+	err      chan error
+	snapshot chan models.GameView
+	entries  chan []engine.LogEntry
+}
 
-func createRoom(game *models.Game, connections map[string]*websocket.Conn) {
-	gameRooms[game.GameID] = game  // Store game instance by its ID
-	game.ShuffleAndDealCards()
-	StartMessageRouter(connections)
-	// Send initial game state
-	BroadcastAndAck(game, connections, "gamestate")
-	// Start the game loop in a separate goroutine
-	go gameLoop(game, connections)
+const (
+	ackTimeout       = 300 * time.Second
+	bidReminderEvery = 20 * time.Second
+	tickInterval     = 1 * time.Second
+)
 
+func newRoom(game *models.Game, connections map[string]*Client) *Room {
+	conns := make(map[string]*Client, len(connections))
+	for playerID, conn := range connections {
+		conns[playerID] = conn
+	}
+	return &Room{
+		ID:           game.GameID,
+		players:      append([]string(nil), game.Players...),
+		commands:     make(chan roomCommand, 2*len(game.Players)),
+		done:         make(chan struct{}),
+		game:         game,
+		log:          engine.NewGameLog(game.State),
+		connections:  conns,
+		pendingAcks:  make(map[string]int),
+		disconnected: make(map[string]time.Time),
+		forfeited:    make(map[string]bool),
+		missed:       make(map[string][]json.RawMessage),
+		seq:          make(map[string]uint64),
+		bots:         make(map[string]*bot.Bot),
+		options:      DefaultRoomOptions(),
+		clock:        tickInterval,
+		timeouts:     make(map[string]int),
+		away:         make(map[string]bool),
+	}
 }
 
-func resetHealthForNextTurn(game *models.Game) {
-    currentTurn := game.State.Turn
+// createRoom starts a room for game. Seats without a connection may be given
+// to bots. Players still connected at the end go back to lobby, if given.
+func createRoom(game *models.Game, connections map[string]*Client, lobby *PlayerConnections, options RoomOptions, bots ...*bot.Bot) *Room {
+	r := newRoom(game, connections)
+	r.options = options
+	r.lobby = lobby
+	for _, b := range bots {
+		r.bots[b.ID] = b
+	}
+	registerRoom(r) // Store room by its game ID and its players
+	StartMessageRouter(r.ID, connections)
+	// Start the room goroutine, it deals the first hands
+	go r.run()
+	return r
+}
 
-    // If the turn is 0, set it to 1
-    if currentTurn == 0 {
-        currentTurn = 1
-    }
+// seated reports whether playerID plays in this room
+func (r *Room) seated(playerID string) bool {
+	for _, id := range r.players {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
 
-    nextPlayer := getCurrentPlayer(&game.State, currentTurn)
+// ************************** REGISTRY ********************************************
 
-    // Reset the health of the next player (i.e., the player's timer)
-    nextPlayer.Health = 100 // or set this to the maximum health/timer value
+// roomRegistry holds the running rooms, safe for use from any goroutine
+type roomRegistry struct {
+	mu      sync.RWMutex
+	rooms   map[string]*Room
+	players map[string]*Room // Active room of each seated player
+}
 
-    fmt.Println("Health reset for player", currentTurn, "to", nextPlayer.Health)
+var gameRooms = &roomRegistry{
+	rooms:   make(map[string]*Room),
+	players: make(map[string]*Room),
 }
 
+func registerRoom(r *Room) {
+	gameRooms.mu.Lock()
+	defer gameRooms.mu.Unlock()
+	gameRooms.rooms[r.ID] = r
+	for _, playerID := range r.players {
+		gameRooms.players[playerID] = r
+	}
+}
 
-// ************************** CARD LOGIC ********************************************
-// compareCards compares two cards, giving precedence to suits and then ranks
-func compareCards(card1, card2 *models.Card) int {
-    // If suits are equal, compare ranks
-    return compareRanks(card1.Rank, card2.Rank)
+func unregisterRoom(r *Room) {
+	gameRooms.mu.Lock()
+	defer gameRooms.mu.Unlock()
+	if gameRooms.rooms[r.ID] == r {
+		delete(gameRooms.rooms, r.ID)
+	}
+	for _, playerID := range r.players {
+		if gameRooms.players[playerID] == r {
+			delete(gameRooms.players, playerID)
+		}
+	}
 }
 
-// compareRanks compares ranks of two cards
-func compareRanks(rank1, rank2 models.Rank) int {
-    rankOrder := map[models.Rank]int{
-        "2":  0,
-        "3":  1,
-        "4":  2,
-        "5":  3,
-        "6":  4,
-        "7":  5,
-        "8":  6,
-        "9":  7,
-        "10": 8,
-        "J":  9,
-        "Q":  10,
-        "K":  11,
-        "A":  12,
-    }
+// roomForPlayer returns the room playerID is seated in, or nil
+func roomForPlayer(playerID string) *Room {
+	gameRooms.mu.RLock()
+	defer gameRooms.mu.RUnlock()
+	return gameRooms.players[playerID]
+}
 
-    return rankOrder[rank1] - rankOrder[rank2]
+// getRoom returns the room playing gameID, or nil
+func getRoom(gameID string) *Room {
+	gameRooms.mu.RLock()
+	defer gameRooms.mu.RUnlock()
+	return gameRooms.rooms[gameID]
 }
 
-func advanceTurn(game *models.Game) {
-    // Advance the turn, making sure 0 becomes 1
-    game.State.Turn = (game.State.Turn + 1) % 5
+// ************************** COMMANDS ********************************************
 
-    if game.State.Turn == 0 {
-        game.State.Turn = 1 // If the turn reaches 0, set it to 1
-    }
+// Play asks the room to put card from playerID's hand on the table
+func (r *Room) Play(playerID string, card models.Card) error {
+	return r.send(roomCommand{kind: commandPlay, playerID: playerID, card: card})
+}
 
-    resetHealthForNextTurn(game)
+// Bid asks the room to record playerID's bid
+func (r *Room) Bid(playerID string, amount int) error {
+	return r.send(roomCommand{kind: commandBid, playerID: playerID, bid: amount})
 }
 
-func isValidCard(player *models.Player, trickSuit models.Suit, gameState models.GameState) bool {
-    // If the player has a card in hand with the TrickSuit, they must play it
-    hasTrickSuit := false
-    for _, card := range player.Hand {
-        if card.Suit == trickSuit {
-            hasTrickSuit = true
-            break
-        }
-    }
+// Redeal asks the room to throw the hands in because playerID's hand is
+// misdealt
+func (r *Room) Redeal(playerID string) error {
+	return r.send(roomCommand{kind: commandRedeal, playerID: playerID})
+}
 
-    // If the player has the trick suit, the card they play must match the trick suit
-    if hasTrickSuit && player.PlayedCard.Suit != trickSuit {
-        fmt.Println("Player must follow suit")
-        return false
-    }
+// Ack records that playerID has processed the last broadcast
+func (r *Room) Ack(playerID string) error {
+	return r.send(roomCommand{kind: commandAck, playerID: playerID})
+}
 
-    // If the player doesn't have the trick suit, they can play any card
-    return true
+// Join binds conn to playerID's seat, replacing any previous connection, and
+// catches the player up on what they missed
+func (r *Room) Join(playerID string, conn *Client) error {
+	return r.send(roomCommand{kind: commandJoin, playerID: playerID, conn: conn})
 }
 
-func processPlayedCard(game *models.Game, currentPlayer *models.Player) {
-    // If it's the first card of the trick, set the trick suit
-    if game.State.TrickSuit == "" {
-        game.State.TrickSuit = currentPlayer.PlayedCard.Suit
-        fmt.Println("Trick suit set to", game.State.TrickSuit)
-    }
+// Leave unbinds conn from playerID's seat. The seat is held for the grace
+// period so the player can resume it.
+func (r *Room) Leave(playerID string, conn *Client) error {
+	return r.send(roomCommand{kind: commandLeave, playerID: playerID, conn: conn})
+}
 
-    // Apply other game logic for the played card
-    
+// Back tells the room that playerID, marked away after timing out, is
+// playing again
+func (r *Room) Back(playerID string) error {
+	return r.send(roomCommand{kind: commandBack, playerID: playerID})
 }
-// ************************** CARD LOGIC - END ********************************************
 
+// Reply sends msg to playerID in sequence with the room's own messages
+func (r *Room) Reply(playerID string, msg protocol.Message) error {
+	return r.send(roomCommand{kind: commandReply, playerID: playerID, reply: msg})
+}
 
+// Snapshot returns the game as seen by playerID
+func (r *Room) Snapshot(playerID string) (models.GameView, error) {
+	reply := make(chan models.GameView, 1)
+	if err := r.send(roomCommand{kind: commandSnapshot, playerID: playerID, snapshot: reply}); err != nil {
+		return models.GameView{}, err
+	}
+	return <-reply, nil
+}
 
+// EventLog returns a copy of the room's event log so far. Use engine.Replay
+// to rebuild the game at any entry.
+func (r *Room) EventLog() ([]engine.LogEntry, error) {
+	reply := make(chan []engine.LogEntry, 1)
+	if err := r.send(roomCommand{kind: commandLog, entries: reply}); err != nil {
+		return nil, err
+	}
+	return <-reply, nil
+}
 
-// ************************** MOVE LOGIC ********************************************
-func allPlayersHavePlayed(game *models.Game) bool {
-    // Check if all players have played a card
-    if game.State.Player1.PlayedCard == nil {
-        return false
-    }
-    if game.State.Player2.PlayedCard == nil {
-        return false
-    }
-    if game.State.Player3.PlayedCard == nil {
-        return false
-    }
-    if game.State.Player4.PlayedCard == nil {
-        return false
-    }
-
-    return true
-}
-
-func HandlePlayerMove(gameID string, playerID string, message []byte) {
-	game := gameRooms[gameID]
-    var playedCardMsg models.PlayedCardMessage
-    
-    // Unmarshal the incoming message to get the played card details
-    err := json.Unmarshal(message, &playedCardMsg)
-    if err != nil {
-        fmt.Println("Error unmarshalling played card message:", err)
-        return
-    }
-
-    // Find the current player by their ID
-    currentPlayer := getPlayerByID(game, playerID)
-    if currentPlayer == nil {
-        fmt.Println("Player not found:", playerID)
-        return
-    }
-
-    // Find the card in the player's hand to set as PlayedCard
-    for i, card := range currentPlayer.Hand {
-        if card.Suit == playedCardMsg.Card.Suit && card.Rank == playedCardMsg.Card.Rank {
-            // Assign the address of the existing card in hand to PlayedCard
-            currentPlayer.PlayedCard = &currentPlayer.Hand[i]
-            fmt.Println("Player", playerID, "played a card:", currentPlayer.PlayedCard)
-            return
-        }
-    }
-}
-
-// resetPlayedCards resets the PlayedCard for all players in the game state
-func resetPlayedCards(game *models.Game, connections map[string]*websocket.Conn) {
-	game.State.Player1.RemovePlayedCard()
-    game.State.Player2.RemovePlayedCard()
-    game.State.Player3.RemovePlayedCard()
-    game.State.Player4.RemovePlayedCard()
-	game.State.TrickSuit = ""
-	BroadcastAndAck(game, connections, "resetcardplayed" )
+// send hands cmd to the room goroutine and waits for the outcome
+func (r *Room) send(cmd roomCommand) error {
+	cmd.err = make(chan error, 1)
+	select {
+	case r.commands <- cmd:
+	case <-r.done:
+		return ErrRoomClosed
+	}
+	select {
+	case err := <-cmd.err:
+		return err
+	case <-r.done:
+		return ErrRoomClosed
+	}
 }
 
+func (r *Room) handle(cmd roomCommand) error {
+	if cmd.kind == commandLog {
+		cmd.entries <- r.log.Entries()
+		return nil
+	}
+	if !r.seated(cmd.playerID) {
+		return engine.ErrUnknownPlayer
+	}
 
-// **********************************MOVE LOGIC - END *********************************
+	switch cmd.kind {
+	case commandPlay:
+		err := r.handlePlay(cmd.playerID, cmd.card)
+		if err == nil {
+			r.markPresent(cmd.playerID)
+		}
+		return err
+	case commandBid:
+		err := r.handleBid(cmd.playerID, cmd.bid)
+		if err == nil {
+			r.markPresent(cmd.playerID)
+		}
+		return err
+	case commandRedeal:
+		err := r.handleRedeal(cmd.playerID)
+		if err == nil {
+			r.markPresent(cmd.playerID)
+		}
+		return err
+	case commandBack:
+		r.markPresent(cmd.playerID)
+		return nil
+	case commandAck:
+		if r.pendingAcks[cmd.playerID] > 0 {
+			r.pendingAcks[cmd.playerID]--
+		}
+		return nil
+	case commandJoin:
+		return r.handleJoin(cmd.playerID, cmd.conn)
+	case commandLeave:
+		r.handleLeave(cmd.playerID, cmd.conn)
+		return nil
+	case commandSnapshot:
+		cmd.snapshot <- r.game.ViewFor(cmd.playerID)
+		return nil
+	case commandReply:
+		r.sendTo(cmd.playerID, cmd.reply)
+		return nil
+	case commandBotPlay:
+		return r.handleBotPlay(cmd.playerID, cmd.card, cmd.botErr)
+	default:
+		return fmt.Errorf("unknown room command %q", cmd.kind)
+	}
+}
 
+// ************************** MOVE LOGIC ********************************************
 
-// ********************************** ROOM STATE LOGIC - END *********************************
+func (r *Room) handlePlay(playerID string, card models.Card) error {
+	events, err := r.apply(engine.PlayCard{PlayerID: playerID, Card: card})
+	if err != nil {
+		// The player keeps their turn, the error reply tells them why
+		fmt.Println("Invalid card played by", playerID+":", err)
+		return err
+	}
 
-func getCurrentPlayer(state *models.GameState, playerNumber int) *models.Player {
-	switch playerNumber {
-	case 1:
-		return &state.Player1
-	case 2:
-		return &state.Player2
-	case 3:
-		return &state.Player3
-	case 4:
-		return &state.Player4
-	default:
-		return nil
+	fmt.Println("Player", playerID, "played a card:", card)
+	r.afterEvents(events)
+	return nil
+}
+
+// apply feeds action to the rules engine, keeps the resulting state and
+// records the action in the event log
+func (r *Room) apply(action engine.Action) ([]engine.Event, error) {
+	state, events, err := engine.Apply(r.game.State, action)
+	if err != nil {
+		return nil, err
 	}
+	r.game.State = state
+	r.log.Record(action, events)
+	for _, b := range r.bots {
+		b.Observe(events)
+	}
+	return events, nil
 }
 
-func getPlayerByID(game *models.Game, playerID string) *models.Player {
-    if game.State.Player1.ID == playerID {
-        return &game.State.Player1
-    }
-    if game.State.Player2.ID == playerID {
-        return &game.State.Player2
-    }
-    if game.State.Player3.ID == playerID {
-        return &game.State.Player3
-    }
-    if game.State.Player4.ID == playerID {
-        return &game.State.Player4
-    }
-    return nil
-}
-
-
-func determineTrickWinner(state *models.GameState) *models.Player {
-    var highestTrumpCard *models.Card
-    var highestSpadeCard *models.Card
-    // var highestOtherCard *models.Card
-    var winner *models.Player
-    var trumpSuit = state.TrickSuit
-
-    for _, player := range []*models.Player{&state.Player1, &state.Player2, &state.Player3, &state.Player4} {
-        if player.PlayedCard != nil {
-            playedCard := player.PlayedCard
-
-            if playedCard.Suit == trumpSuit && highestSpadeCard == nil {
-                // Track the highest card in the trump suit
-                if highestTrumpCard == nil || compareCards(playedCard, highestTrumpCard) > 0 {
-                    highestTrumpCard = playedCard
-                    winner = player
-                }
-            } else if playedCard.Suit == "S" {
-                log.Println("Turup played... ")
-                // Track the highest spade card if no trump suit is played
-                if highestSpadeCard == nil || compareCards(playedCard, highestSpadeCard) > 0 {
-                log.Println("Highest spade card set, winner till now.. ", player.ID)
-                    highestSpadeCard = playedCard
-                    winner = player
-                }
-            } 
-            // else {
-            //     // Track the highest card in other suits if no trump suit or spades are played
-            //     if highestOtherCard == nil || compareCards(playedCard, highestOtherCard) > 0 {
-            //         highestOtherCard = playedCard
-            //         winner = player
-            //     }
-            // }
-        }
-    }
-
-    // Final decision: priority order spades > trump > other
-    if highestSpadeCard != nil {
-        return winner // highest spade card is the winner
-    } else if highestTrumpCard != nil {
-        return winner //  trump card is the winner if no trump suit is played
-    } else {
-        return winner // otherwise, the highest other card is the winner
-    }
-}
-
-
-// updateGameStateAfterTrick updates the game state after a trick is completed
-func updateGameStateAfterTrick(game *models.Game, winner *models.Player, connections map[string]*websocket.Conn) {
-    // Update the round winner in the GameState
-    game.State.RoundWinner = winner
-	winner.Score +=1
-    // Increment the winner's score
-    if game.State.Scores == nil {
-        game.State.Scores = make(map[string]int)
-    }
-    
-    game.State.Scores[winner.ID]++
-
-    // Optional: Reset played cards for the next trick
-    resetPlayedCards(game, connections)
-
-    // Debug log to confirm the updates
-    fmt.Printf("Updated game state: round winner is %s, new score is %d\n", winner.ID, game.State.Scores[winner.ID])
-}
-
-func isGameOver(state *models.GameState) bool {
-    // Check each player's hand for emptiness
-    for _, player := range []*models.Player{&state.Player1, &state.Player2, &state.Player3, &state.Player4} {
-        if len(player.Hand) == 0 { // Assuming Hand is a slice of cards
-            return true // Game is over if any player's hand is empty
-        }
-    }
-    return false // Game continues if all players have cards in their hands
+// afterEvents tells the players what the engine just did and moves the game
+// on to the next deal when needed
+func (r *Room) afterEvents(events []engine.Event) {
+	for _, event := range events {
+		switch e := event.(type) {
+		case engine.Misdeal:
+			r.announceMisdeal(e)
+		case engine.AutoBid:
+			r.notifyAll(protocol.AutoBid{PlayerID: e.PlayerID, Bid: e.Amount})
+		case engine.AutoPlayed:
+			r.notifyAll(protocol.AutoPlayed{PlayerID: e.PlayerID, Card: e.Card})
+		case engine.CardPlayed:
+			r.broadcast("cardplayed")
+		case engine.BiddingComplete:
+			fmt.Println("All bids received. Broadcasting bidding complete.")
+			r.broadcast("biddingcomplete")
+			r.broadcastAndAck("gamestate")
+		case engine.TrickWon:
+			r.broadcastAndAck("trickwon")
+			r.broadcastAndAck("resetcardplayed")
+			r.broadcast("gamestate")
+		case engine.DealOver:
+			r.broadcast("dealover") // Not acknowledged by clients, the next deal's gamestate is
+		case engine.GameOver:
+			r.broadcast("gameover")
+		}
+	}
+
+	if r.game.State.Phase == models.PhaseDealing {
+		r.startDeal()
+	}
+	r.startTurnTimer()
 }
 
+// startDeal deals fresh hands for the next deal of the match. Hands the rules
+// throw in straight away are dealt again until there is one to bid on.
+func (r *Room) startDeal() {
+	game := r.game
+	for game.State.Phase == models.PhaseDealing {
+		seed := models.NewSeed()
+		hands := game.State.Dealing.Hands(len(game.Players), seed)
+		events, err := r.apply(engine.Deal{Seed: seed, Hands: hands})
+		if err != nil {
+			fmt.Println("Error dealing cards:", err)
+			return
+		}
+		for _, event := range events {
+			if misdeal, ok := event.(engine.Misdeal); ok {
+				r.announceMisdeal(misdeal)
+			}
+		}
+	}
+	fmt.Printf("Deal %d of %d started\n", game.State.Deal, engine.NumDeals(&game.State))
 
+	r.broadcast("dealstarted")
+	r.broadcastAndAck("gamestate")
+	r.openRedealWindow(time.Now())
+	r.startTurnTimer()
+}
 
+// **********************************MOVE LOGIC - END *********************************
+
+// ************************** BROADCAST ********************************************
+
+// broadcast sends stateType to every seat, each with its own redacted payload
+func (r *Room) broadcast(stateType string) {
+	for _, playerID := range r.players {
+		r.sendTo(playerID, buildStateMessage(r.game, stateType, playerID))
+	}
+}
+
+// sendTo writes msg to playerID's connection, numbered in the player's
+// sequence for this game. While the player is disconnected it is kept for
+// them instead, unless it is only meaningful live.
+func (r *Room) sendTo(playerID string, msg protocol.Message) {
+	conn, connected := r.connections[playerID]
+	_, away := r.disconnected[playerID]
+	keep := !connected && away && !transientMessages[msg.MessageType()]
+	if !connected && !keep {
+		return
+	}
+
+	jsonMessage, err := protocol.Encode(r.seq[playerID]+1, msg)
+	if err != nil {
+		log.Printf("Error marshaling %s for player %s: %v\n", msg.MessageType(), playerID, err)
+		return
+	}
+	r.seq[playerID]++
 
+	if keep {
+		r.keepMissed(playerID, jsonMessage)
+		return
+	}
+	if err := conn.Send(jsonMessage); err != nil {
+		log.Printf("Error sending %s to player %s: %v\n", msg.MessageType(), playerID, err)
+	}
+}
+
+// notifyAll sends the same message to every seat
+func (r *Room) notifyAll(msg protocol.Message) {
+	for _, playerID := range r.players {
+		r.sendTo(playerID, msg)
+	}
+}
+
+// broadcastAndAck broadcasts stateType and pauses the turn timers until every
+// connected player has acknowledged it, or ackTimeout has passed
+func (r *Room) broadcastAndAck(stateType string) {
+	r.broadcast(stateType)
+	for playerID := range r.connections {
+		r.pendingAcks[playerID]++
+	}
+	r.ackDeadline = time.Now().Add(ackTimeout)
+}
+
+// awaitingAcks reports whether a broadcast is still waiting on acknowledgments
+func (r *Room) awaitingAcks(now time.Time) bool {
+	waiting := false
+	for _, count := range r.pendingAcks {
+		if count > 0 {
+			waiting = true
+			break
+		}
+	}
+	if waiting && now.After(r.ackDeadline) {
+		fmt.Println("Acknowledgment timeout. Players who did not acknowledge:", r.pendingAcks)
+		r.pendingAcks = make(map[string]int)
+		return false
+	}
+	return waiting
+}
 
 //*************************** MAIN LOOP ***********************************
 
-func gameLoop(game *models.Game, connections map[string]*websocket.Conn) {
-	ticker := time.NewTicker(1 * time.Second)
+// run is the room goroutine. It is the only code that touches the game
+// state and the connections, so no locking is needed.
+func (r *Room) run() {
+	ticker := time.NewTicker(r.clock)
 	defer ticker.Stop()
+	defer close(r.done)
+	defer r.returnToLobby()
+	defer unregisterRoom(r) // Before the players can queue again
 	fmt.Println("Game loop started")
 
-	// Players := []*models.Player{
-	// 	&game.State.Player1,
-	// 	&game.State.Player2,
-	// 	&game.State.Player3,
-	// 	&game.State.Player4,
-		
-	// }
-    
-	WaitForAllBids(game, connections);
-	
-	// Main Game Loop
-	for range ticker.C {
-		fmt.Println("Game loop ticked")
-		currentPlayerNumber := game.State.Turn
-		currentPlayer := getCurrentPlayer(&game.State, currentPlayerNumber)
-
-		// Check if the player's health has dropped (indicating timer expiration)
-		currentPlayer.Health -= 1
-		fmt.Println("Current player health:", currentPlayer.Health)
-		BroadcastGameState(game, connections, "healthstate")
-		if currentPlayer.Health <= 0 {
-			// Timeout: move to the next player if the current player did not play a card
-			advanceTurn(game)
-			resetHealthForNextTurn(game)
-			continue
-		}
-
-		// Wait for the current player to play a card
-		if currentPlayer.PlayedCard == nil {
-			continue
-		}
+	// Tell everyone where they sit before anything else
+	for seat, playerID := range r.players {
+		r.sendTo(playerID, protocol.MatchFound{RoomID: r.ID, Seat: seat + 1, Players: r.players})
+	}
 
-		// Process the played card
-		processPlayedCard(game, currentPlayer)
+	if r.game.State.Phase == models.PhaseDealing {
+		r.startDeal()
+	}
 
-		// Validate the played card
-		if isValidCard(currentPlayer, game.State.TrickSuit, game.State) {
-			BroadcastGameState(game, connections, "cardplayed")
-		} else {
-			fmt.Println("Invalid card played")
-			continue
+	for {
+		r.moveBots(time.Now())
+		if r.game.State.Phase == models.PhaseOver {
+			break
 		}
 
-        hasWinner := false
-		// Check if all players have played (end of trick)
-		if allPlayersHavePlayed(game) {
-			winner := determineTrickWinner(&game.State)
-            hasWinner = true
-			updateGameStateAfterTrick(game, winner, connections)
-			BroadcastAndAck(game, connections, "trickwon")
-			BroadcastGameState(game, connections, "gamestate")
-            game.State.Turn = game.State.GetPlayerPosition(*winner)
+		select {
+		case cmd := <-r.commands:
+			cmd.err <- r.handle(cmd)
+		case now := <-ticker.C:
+			r.tick(now)
 		}
-
-		// Move to the next turn
-        if !hasWinner {
-		    advanceTurn(game)
-        }
-        if isGameOver(&game.State) {
-            BroadcastGameState(game, connections, "gameover")
-            log.Println("Game Over!! Thank you for playing...")
-            return
-        }
-		resetHealthForNextTurn(game)
 	}
+	log.Println("Game Over!! Thank you for playing...")
+	r.rate()
+	r.saveLog()
 }
 
+// returnToLobby hands the players still connected back to the lobby, so they
+// can play again without reconnecting
+func (r *Room) returnToLobby() {
+	if r.lobby == nil {
+		return
+	}
+	for _, conn := range r.connections {
+		select {
+		case <-conn.Done():
+		default:
+			r.lobby.AddPlayer(conn)
+		}
+	}
+}
 
+// tick runs the clocks: the bidding timer and reminders, and the turn timer
+func (r *Room) tick(now time.Time) {
+	r.tickPresence(now)
+	if r.awaitingAcks(now) {
+		return
+	}
 
+	switch r.game.State.Phase {
+	case models.PhaseBidding:
+		r.tickBidding(now)
+	case models.PhasePlaying:
+		r.tickTurn()
+	}
+}
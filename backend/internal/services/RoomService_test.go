@@ -0,0 +1,409 @@
+package services
+
+import (
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testEnvelope is a server message as read by a test client
+type testEnvelope struct {
+	Type string
+	Data json.RawMessage
+}
+
+// testFrontend reads everything sent to a test connection and acknowledges
+// what the frontend acknowledges, and nothing else. The messages read are
+// passed on in received, as long as there is room for them.
+type testFrontend struct {
+	conn     *websocket.Conn
+	mu       sync.Mutex // Writes come from the reader and the test
+	received chan testEnvelope
+}
+
+func acknowledgeLikeTheFrontend(conn *websocket.Conn) *testFrontend {
+	f := &testFrontend{conn: conn, received: make(chan testEnvelope, 1024)}
+	go func() {
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var envelope testEnvelope
+			json.Unmarshal(raw, &envelope)
+			switch envelope.Type {
+			case "gamestate", "trickwon", "resetcardplayed":
+				f.send(`{"type":"acknowledgment"}`)
+			}
+			select {
+			case f.received <- envelope:
+			default:
+			}
+		}
+	}()
+	return f
+}
+
+// send writes raw as a client message
+func (f *testFrontend) send(raw string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.conn.WriteMessage(websocket.TextMessage, []byte(raw))
+}
+
+// playUntilOver acts for playerID until the room closes: it bids when asked
+// and, on its turn, tries the cards in its hand until the room accepts one.
+func playUntilOver(t *testing.T, r *Room, playerID string) {
+	for {
+		view, err := r.Snapshot(playerID)
+		if errors.Is(err, ErrRoomClosed) {
+			return
+		}
+		if err != nil {
+			t.Errorf("%s snapshot: %v", playerID, err)
+			return
+		}
+
+		var me models.PlayerView
+		for i, seat := range view.State.Seats {
+			if seat.ID == playerID {
+				me = seat
+				if view.State.Turn != i+1 {
+					me.Hand = nil
+				}
+			}
+		}
+
+		switch view.State.Phase {
+		case models.PhaseBidding:
+			if view.State.Bidder == playerID {
+				r.Bid(playerID, 3)
+				continue
+			}
+		case models.PhasePlaying:
+			for _, card := range me.Hand {
+				if r.Play(playerID, card) == nil {
+					break
+				}
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManyRoomsPlayConcurrently(t *testing.T) {
+	const numRooms = 16
+	rooms := startTestRooms(t, numRooms)
+
+	var wg sync.WaitGroup
+	for _, r := range rooms {
+		for _, playerID := range r.players {
+			wg.Add(1)
+			go func(r *Room, playerID string) {
+				defer wg.Done()
+				playUntilOver(t, r, playerID)
+			}(r, playerID)
+		}
+
+		// Moves arriving over the socket for a player who isn't on turn race with the room
+		wg.Add(1)
+		go func(r *Room) {
+			defer wg.Done()
+			for getRoom(r.ID) != nil {
+				routeMessage(r.ID, r.players[1], []byte(`{"type":"playcard","playerId":"x","card":{"rank":"A","suit":"S"}}`))
+				time.Sleep(time.Millisecond)
+			}
+		}(r)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(30 * time.Second):
+		t.Fatal("games did not finish")
+	}
+
+	for _, r := range rooms {
+		if getRoom(r.ID) != nil {
+			t.Errorf("room %s still registered after game over", r.ID)
+		}
+		if r.game.State.Phase != models.PhaseOver {
+			t.Errorf("room %s ended in phase %q", r.ID, r.game.State.Phase)
+		}
+	}
+}
+
+func TestRoomErrorsHaveTheirOwnCodes(t *testing.T) {
+	for err, code := range map[error]string{
+		ErrRoomClosed:        protocol.CodeRoomClosed,
+		ErrSeatForfeited:     protocol.CodeSeatForfeited,
+		ErrNotInLobby:        protocol.CodeNotInLobby,
+		ErrMatchmakerStopped: protocol.CodeMatchmakerStopped,
+		ErrRoomChanged:       protocol.CodeRoomChanged,
+	} {
+		if got := protocol.CodeFor(fmt.Errorf("wrapped: %w", err)); got != code {
+			t.Errorf("CodeFor(%v) = %q, want %q", err, got, code)
+		}
+	}
+}
+
+func TestGameIDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		gameID := newGameID()
+		if seen[gameID] {
+			t.Fatalf("game ID %s given out twice", gameID)
+		}
+		seen[gameID] = true
+	}
+}
+
+func TestBotsFillARoomAroundOnePlayer(t *testing.T) {
+	game := &models.Game{GameID: "bots-game", Players: []string{"human"}}
+	bots := newBots(game.GameID, 2, 5, bot.Medium)
+	for _, b := range bots {
+		game.Players = append(game.Players, b.ID)
+	}
+	game.State = models.GameState{
+		Seats:    models.NewSeats(game.Players),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: 2,
+	}
+
+	// The bots wait for the human while a redeal may be asked for
+	options := DefaultRoomOptions()
+	options.RedealWindow = 10 * time.Millisecond
+	options.Ratings = NewRatingStore()
+	r := createRoom(game, nil, nil, options, bots...)
+	done := make(chan struct{})
+	go func() {
+		playUntilOver(t, r, "human")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatal("game with bots did not finish")
+	}
+	if len(r.game.State.History) != 2 {
+		t.Errorf("played %d deals, want 2", len(r.game.State.History))
+	}
+
+	// Only the human is rated
+	if history := options.Ratings.Get("human").History; len(history) == 0 || history[len(history)-1].GameID != game.GameID {
+		t.Errorf("human's rating history %+v misses %s", history, game.GameID)
+	}
+	for _, b := range bots {
+		if rated := options.Ratings.Get(b.ID); rated.Games != 0 {
+			t.Errorf("bot %s was rated: %+v", b.ID, rated)
+		}
+	}
+}
+
+func TestRoomAnswersWhileAHardBotSearches(t *testing.T) {
+	game := &models.Game{GameID: "thinking-game"}
+	bots := newBots(game.GameID, 0, 1, bot.Hard)
+	bots[0].Budget = bot.Budget{Samples: 1 << 30, Time: 500 * time.Millisecond}
+	game.Players = []string{bots[0].ID, "human"}
+	game.State = models.GameState{
+		Seats:    models.NewSeats(game.Players),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: 1,
+	}
+	options := DefaultRoomOptions()
+	options.RedealWindow = 10 * time.Millisecond
+	r := createRoom(game, nil, nil, options, bots...)
+	t.Cleanup(func() { unregisterRoom(r) })
+
+	// The bot bids first and leads once the human has bid
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if time.Now().After(deadline) {
+			t.Fatal("bot did not get to lead")
+		}
+		started := time.Now()
+		view, err := r.Snapshot("human")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if view.State.Phase == models.PhasePlaying {
+			if waited := time.Since(started); waited > 100*time.Millisecond {
+				t.Errorf("snapshot took %v while the bot searched", waited)
+			}
+			break
+		}
+		if view.State.Bidder == "human" {
+			if err := r.Bid("human", 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// It still plays its card once it has chosen
+	for {
+		if time.Now().After(deadline) {
+			t.Fatal("bot did not lead")
+		}
+		view, err := r.Snapshot("human")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if view.State.Turn == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDealsFollowEachOtherWhilePlayersAcknowledge(t *testing.T) {
+	server, client := connectTestPlayer(t, "acker")
+	game := &models.Game{GameID: "acks-game", Players: []string{"acker"}}
+	bots := newBots(game.GameID, 2, 5, bot.Easy)
+	for _, b := range bots {
+		game.Players = append(game.Players, b.ID)
+	}
+	game.State = models.GameState{
+		Seats:    models.NewSeats(game.Players),
+		Turn:     1,
+		Phase:    models.PhaseDealing,
+		NumDeals: 2,
+	}
+
+	options := DefaultRoomOptions()
+	options.RedealWindow = 10 * time.Millisecond
+	r := createRoom(game, map[string]*Client{"acker": server}, nil, options, bots...)
+
+	acknowledgeLikeTheFrontend(client)
+
+	done := make(chan struct{})
+	go func() {
+		playUntilOver(t, r, "acker")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatal("match stalled between deals")
+	}
+	if len(r.game.State.History) != 2 {
+		t.Errorf("played %d deals, want 2", len(r.game.State.History))
+	}
+}
+
+func TestTimedOutTurnsAreAutoPlayed(t *testing.T) {
+	players := []string{"idle1", "idle2"}
+	game := &models.Game{
+		GameID:  "idle-game",
+		Players: players,
+		State: models.GameState{
+			Seats:    models.NewSeats(players),
+			Turn:     1,
+			Phase:    models.PhaseDealing,
+			NumDeals: 1,
+		},
+	}
+	r := newRoom(game, nil)
+	r.clock = time.Millisecond
+	r.options.TurnDuration = 3 * time.Millisecond
+	registerRoom(r)
+	go r.run()
+	t.Cleanup(func() { unregisterRoom(r) })
+
+	// Bid, then never play a card
+	for _, playerID := range players {
+		if err := r.Bid(playerID, 1); err != nil {
+			t.Fatalf("%s bid: %v", playerID, err)
+		}
+	}
+
+	select {
+	case <-r.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("game with idle players never finished")
+	}
+
+	for _, playerID := range players {
+		if !r.away[playerID] {
+			t.Errorf("%s was not marked away", playerID)
+		}
+	}
+	autoPlayed := 0
+	for _, entry := range r.log.Entries() {
+		if entry.Kind == engine.EntryTimeout {
+			autoPlayed++
+		}
+	}
+	if autoPlayed != 52 {
+		t.Errorf("%d cards auto-played, want all 52", autoPlayed)
+	}
+}
+
+func TestRedealRequestDealsAgain(t *testing.T) {
+	t.Cleanup(engine.RegisterMisdealCondition("anyhand", engine.MisdealCondition{Reason: "any hand", Holds: func([]models.Card) bool { return true }}))
+
+	rules := models.StrictCallBreak
+	rules.Misdeals = []string{"anyhand"}
+	players := []string{"lucky", "unlucky"}
+	game := &models.Game{
+		GameID:  "redeal-game",
+		Players: players,
+		State: models.GameState{
+			Seats:    models.NewSeats(players),
+			Phase:    models.PhaseDealing,
+			NumDeals: 1,
+			Rules:    rules,
+		},
+	}
+	r := newRoom(game, nil)
+	registerRoom(r)
+	go r.run()
+	t.Cleanup(func() { unregisterRoom(r) })
+
+	if err := r.Redeal("unlucky"); err != nil {
+		t.Fatalf("redeal: %v", err)
+	}
+	entries, err := r.EventLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, entry := range entries {
+		kinds = append(kinds, entry.Kind)
+	}
+	want := []string{engine.EntryStart, engine.EntryDeal, engine.EntryRedeal, engine.EntryMisdeal, engine.EntryDeal}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("log kinds = %v, want %v", kinds, want)
+	}
+
+	view, err := r.Snapshot("lucky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.State.Phase != models.PhaseBidding || view.State.Deal != 1 {
+		t.Fatalf("after the redeal phase %q, deal %d; want bidding on deal 1", view.State.Phase, view.State.Deal)
+	}
+	if err := r.Bid(view.State.Bidder, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Redeal("unlucky"); !errors.Is(err, engine.ErrBiddingStarted) {
+		t.Errorf("redeal after a bid: err = %v, want %v", err, engine.ErrBiddingStarted)
+	}
+}
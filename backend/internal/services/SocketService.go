@@ -1,159 +1,64 @@
 package services
 
 import (
+	"dealer-backend/internal/engine"
 	"dealer-backend/internal/models"
-	"encoding/json"
-	"fmt"
-	"log"
-
-	// "sync"
-	"time"
-
-	"github.com/gorilla/websocket"
+	"dealer-backend/internal/protocol"
 )
 
+// buildStateMessage builds the stateType message as seen by viewerID, so
+// hidden cards never reach the wrong seat. It returns nil for unknown types.
+func buildStateMessage(game *models.Game, stateType string, viewerID string) protocol.Message {
+	state := &game.State
 
-
-func BroadcastAndAck(game *models.Game, connections map[string]*websocket.Conn, broadcastType string) {
-	log.Println("Initializing acknowledgment listener...")
-
-	log.Println("Broadcasting game state...")
-	BroadcastGameState(game, connections, broadcastType)
-
-	log.Println("Game state broadcasted, waiting for acknowledgments...")
-	acknowledgmentReceived := WaitForAcks(ackChannel, connections)
-
-	if acknowledgmentReceived {
-		fmt.Println("All acknowledgments received for", broadcastType)
-	} else {
-		fmt.Println("No acknowledgment received for game state change. Timed out...")
+	// Determine the current player based on the turn
+	var currentPlayer models.Player
+	if player := engine.CurrentPlayer(state); player != nil {
+		currentPlayer = *player
 	}
-}
-
-
-func WaitForAcks(ackChannel chan string, connections map[string]*websocket.Conn) bool {
-    timeout := time.After(300 * time.Second)
-    expectedAckCount := len(connections)
-    acknowledgedPlayers := make(map[string]bool)
-    ackCount := 0
-    for {
-        select {
-        case playerID, ok := <-ackChannel:
-            if !ok {
-                fmt.Println("Ack channel closed")
-                return ackCount == expectedAckCount
-            }
-            if !acknowledgedPlayers[playerID] {
-                acknowledgedPlayers[playerID] = true
-                ackCount++
-                fmt.Println("Received acknowledgment from:", playerID)
-            }
-            if ackCount == expectedAckCount {
-                return true
-            }
-        case <-timeout:
-            var nonAcknowledgedPlayers []string
-            for playerID := range connections {
-                if !acknowledgedPlayers[playerID] {
-                    nonAcknowledgedPlayers = append(nonAcknowledgedPlayers, playerID)
-                }
-            }
-            fmt.Println("Acknowledgment timeout. Players who did not acknowledge:", nonAcknowledgedPlayers)
-            return false
-        }
-    }
-}
-
-
 
-func BroadcastGameState(game *models.Game, connections map[string]*websocket.Conn, stateType string) {
-	
-	var message map[string]interface{}
-    var currentPlayer models.Player
-
-    // Determine the current player based on the turn
-    switch game.State.Turn {
-        case 1:
-            currentPlayer = game.State.Player1
-        case 2:
-            currentPlayer = game.State.Player2
-        case 3:
-            currentPlayer = game.State.Player3
-        case 4:
-            currentPlayer = game.State.Player4
-        default:
-            // Handle cases where Turn doesn't match (optional)
-            currentPlayer = models.Player{}
-        }
-	// Assuming game is of type *models.Game
-	if stateType == "gamestate" {
-		message = map[string]interface{}{
-			"type": stateType,
-			"data": game, // Send the full game object if stateType is "gamestate"
-		}
-	} else if stateType == "healthstate" {
-		message = map[string]interface{}{
-			"type": stateType,
-			"data": map[string]interface{}{
-				"player": currentPlayer.ID,  // Use current player ID
-				"health": currentPlayer.Health,  // Use current player Health
-			},
-		}
-	}else if stateType == "cardplayed" {
-		message = map[string]interface{}{
-			"type": stateType,
-			"data": map[string]interface{}{
-				"playerId": currentPlayer.ID,  // Use current player ID
-				"card": currentPlayer.PlayedCard,  // Use current player Health
-			},
+	switch stateType {
+	case "gamestate":
+		return protocol.GameState(game.ViewFor(viewerID)) // Only the viewer's own hand is included
+	case "healthstate":
+		return protocol.HealthState{Player: currentPlayer.ID, Health: currentPlayer.Health}
+	case "cardplayed":
+		// The engine has already moved the turn on, so use the last play
+		var lastPlay models.PlayedCardMessage
+		if state.LastPlay != nil {
+			lastPlay = *state.LastPlay
 		}
-    } else if stateType == "trickwon" {
-		message = map[string]interface{}{
-			"type": stateType,
-			"data": map[string]interface{}{
-				"player": game.State.RoundWinner,  // Use current player ID
-				"score": game.State.RoundWinner.Score,
-			}, 
+		return protocol.CardPlayed{PlayerID: lastPlay.PlayerID, Card: lastPlay.Card}
+	case "trickwon":
+		message := protocol.TrickWon{}
+		if state.RoundWinner != nil {
+			view := state.RoundWinner.ViewFor(viewerID) // Redacted unless the viewer won the trick
+			message.Player = &view
+			message.Score = state.RoundWinner.Score
 		}
-    }else if stateType == "resetcardplayed" {
-		message = map[string]interface{}{
-			"type": stateType,
+		return message
+	case "resetcardplayed":
+		return protocol.ResetCardPlayed{}
+	case "biddingcomplete":
+		return protocol.BiddingComplete{}
+	case "bidupdate":
+		return protocol.BidUpdate{PlayerID: currentPlayer.ID, Bid: currentPlayer.Bid}
+	case "dealstarted":
+		// The dealer bids last
+		message := protocol.DealStarted{Deal: state.Deal, NumDeals: engine.NumDeals(state), BidOrder: state.BidOrder()}
+		if len(message.BidOrder) > 0 {
+			message.Dealer = message.BidOrder[len(message.BidOrder)-1]
 		}
-    }else if stateType == "biddingcomplete" {
-		message = map[string]interface{}{
-			"type": stateType,
-		}
-    }else if stateType == "bidupdate" {
-		message = map[string]interface{}{
-			"type": stateType,
-			"data": map[string]interface{}{
-				"playerId": currentPlayer.ID,
-				"bid": currentPlayer.Bid,
-			}, 	
-		}
-    }else if stateType == "gameover" {
-		message = map[string]interface{}{
-			"type": stateType,
-			"data": map[string]interface{}{
-				"playerId": currentPlayer.ID,
-
-			}, 	
-		}
-    }
-	
-	
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		// Handle error (e.g., log it)
-		return
-	}
-
-	for _, conn := range connections {
-		err := conn.WriteMessage(websocket.TextMessage, jsonMessage)
-		if err != nil {
-			// Handle error (e.g., log it or remove the connection)
+		return message
+	case "dealover":
+		message := protocol.DealOver{Deal: state.Deal, NumDeals: engine.NumDeals(state), Totals: state.Totals}
+		if len(state.History) > 0 {
+			message.Result = &state.History[len(state.History)-1]
 		}
+		return message
+	case "gameover":
+		return protocol.GameOver{PlayerID: currentPlayer.ID, Standings: engine.Standings(state), History: state.History}
+	default:
+		return nil
 	}
 }
-
-
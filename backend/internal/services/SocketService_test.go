@@ -0,0 +1,120 @@
+package services
+
+import (
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/protocol"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var allStateTypes = []string{
+	"gamestate",
+	"healthstate",
+	"cardplayed",
+	"trickwon",
+	"resetcardplayed",
+	"biddingcomplete",
+	"dealstarted",
+	"bidupdate",
+	"dealover",
+	"gameover",
+}
+
+func newTestGame() *models.Game {
+	game := &models.Game{
+		GameID:  "game-test",
+		Players: []string{"p1", "p2", "p3", "p4"},
+		State: models.GameState{
+			Seats: models.NewSeats([]string{"p1", "p2", "p3", "p4"}),
+			Turn:  2,
+		},
+	}
+	game.ShuffleAndDealCards()
+
+	// p2 has a card on the table and won the previous trick, so both the
+	// played card and the round winner are part of the broadcast.
+	p2 := &game.State.Seats[1]
+	p2.PlayedCard = &p2.Hand[0]
+	game.State.TrickSuit = p2.PlayedCard.Suit
+	game.State.RoundWinner = p2
+	return game
+}
+
+func cardJSON(t *testing.T, card models.Card) string {
+	t.Helper()
+	b, err := json.Marshal(card)
+	if err != nil {
+		t.Fatalf("marshal card: %v", err)
+	}
+	return string(b)
+}
+
+func TestBroadcastMessagesNeverLeakHiddenCards(t *testing.T) {
+	game := newTestGame()
+	for _, viewer := range game.Players {
+		for _, stateType := range allStateTypes {
+			payload, err := json.Marshal(buildStateMessage(game, stateType, viewer))
+			if err != nil {
+				t.Fatalf("%s for %s: marshal: %v", stateType, viewer, err)
+			}
+
+			for _, seat := range game.State.Players() {
+				if seat.ID == viewer {
+					continue
+				}
+				for _, card := range seat.Hand {
+					if seat.PlayedCard != nil && card == *seat.PlayedCard {
+						continue
+					}
+					if strings.Contains(string(payload), cardJSON(t, card)) {
+						t.Errorf("%s sent to %s leaks %s from %s's hand", stateType, viewer, card.Identifier(), seat.ID)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGameStateMessageIncludesOwnHandOnly(t *testing.T) {
+	game := newTestGame()
+
+	message := buildStateMessage(game, "gamestate", "p3")
+	state, ok := message.(protocol.GameState)
+	if !ok {
+		t.Fatalf("gamestate message is %T, want protocol.GameState", message)
+	}
+	view := models.GameView(state)
+
+	if got, want := len(view.State.Seats[2].Hand), len(game.State.Seats[2].Hand); got != want {
+		t.Errorf("own hand has %d cards, want %d", got, want)
+	}
+	for _, opponent := range []models.PlayerView{view.State.Seats[0], view.State.Seats[1], view.State.Seats[3]} {
+		if len(opponent.Hand) != 0 {
+			t.Errorf("opponent %s hand visible: %v", opponent.ID, opponent.Hand)
+		}
+		if opponent.HandCount != 13 {
+			t.Errorf("opponent %s hand_count = %d, want 13", opponent.ID, opponent.HandCount)
+		}
+	}
+	if played := view.State.Seats[1].PlayedCard; played == nil || *played != *game.State.Seats[1].PlayedCard {
+		t.Errorf("played card of p2 not public: %v", played)
+	}
+
+	// Legacy player1..playerN keys carry the same redacted seats
+	var wire struct {
+		State map[string]json.RawMessage
+	}
+	payload, err := json.Marshal(view)
+	if err != nil {
+		t.Fatalf("marshal view: %v", err)
+	}
+	if err := json.Unmarshal(payload, &wire); err != nil {
+		t.Fatalf("unmarshal view: %v", err)
+	}
+	for _, key := range []string{"seats", "player1", "player2", "player3", "player4"} {
+		if _, ok := wire.State[key]; !ok {
+			t.Errorf("State.%s missing from wire format", key)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"dealer-backend/internal/bot"
+	"dealer-backend/internal/engine"
+	"dealer-backend/internal/models"
+	"dealer-backend/internal/protocol"
+	"fmt"
+	"time"
+)
+
+// AutoPlayPolicy decides which card is played for a player whose turn timed out
+type AutoPlayPolicy string
+
+const (
+	AutoPlayLowest AutoPlayPolicy = "lowest" // Lowest legal card
+	AutoPlayBot    AutoPlayPolicy = "bot"    // What a medium bot would play
+)
+
+// RoomOptions are the per-room settings
+type RoomOptions struct {
+	TurnDuration time.Duration // Time a player has to play a card
+	BidDuration  time.Duration // Time a player has to bid before the lowest bid is placed for them
+	RedealWindow time.Duration // Time after the deal for a player with a misdealt hand to ask for a redeal
+	AutoPlay     AutoPlayPolicy
+	AwayAfter    int           // Consecutive timeouts before a player is marked away, never when zero
+	GracePeriod  time.Duration // Time a seat is held for a disconnected player before it is forfeited
+	Ratings      *RatingStore  // Where the result of the game is recorded, not rated when nil
+}
+
+// DefaultRoomOptions returns the settings of rooms created by matchmaking
+func DefaultRoomOptions() RoomOptions {
+	return RoomOptions{
+		TurnDuration: 100 * time.Second,
+		BidDuration:  60 * time.Second,
+		RedealWindow: 10 * time.Second,
+		AutoPlay:     AutoPlayLowest,
+		AwayAfter:    2,
+		GracePeriod:  60 * time.Second,
+		Ratings:      Ratings,
+	}
+}
+
+// turnTicks is the length of a turn in ticks of the room clock
+func (r *Room) turnTicks() int {
+	ticks := int(r.options.TurnDuration / r.clock)
+	if ticks < 1 {
+		return 1
+	}
+	return ticks
+}
+
+// startTurnTimer gives the player whose turn it is a full timer
+func (r *Room) startTurnTimer() {
+	if r.game.State.Phase == models.PhaseBidding {
+		r.startBidTimer(time.Now())
+		return
+	}
+	if r.game.State.Phase != models.PhasePlaying {
+		return
+	}
+	current := engine.CurrentPlayer(&r.game.State)
+	if current == nil {
+		return
+	}
+	r.turnLeft = r.turnTicks()
+	current.Health = 100
+}
+
+// tickTurn runs the current player's timer down, shown to the players as
+// health from 100 to 0, and plays for them when it runs out
+func (r *Room) tickTurn() {
+	current := engine.CurrentPlayer(&r.game.State)
+	if current == nil {
+		return
+	}
+
+	// Nobody is waiting for an absent player, time them out straight away
+	if r.absent(current.ID) {
+		r.turnLeft = 0
+	} else {
+		r.turnLeft--
+	}
+	current.Health = (r.turnLeft*100 + r.turnTicks() - 1) / r.turnTicks()
+	if current.Health < 0 {
+		current.Health = 0
+	}
+	r.broadcast("healthstate")
+	if r.turnLeft > 0 || r.thinking {
+		return // A thinking bot is never timed out, it plays as soon as it has chosen
+	}
+
+	r.timeoutTurn(current.ID)
+}
+
+// timeoutTurn plays a card for playerID according to the room's policy
+func (r *Room) timeoutTurn(playerID string) {
+	timeout := engine.Timeout{PlayerID: playerID}
+	if r.options.AutoPlay == AutoPlayBot {
+		standIn := bot.New(playerID, bot.Medium, newBotSeed())
+		if card, err := standIn.Play(r.game.State); err == nil {
+			timeout.Card = &card
+		}
+	}
+
+	events, err := r.apply(timeout)
+	if err != nil {
+		fmt.Println("Error applying timeout for", playerID+":", err)
+		return
+	}
+	r.noteTimeout(playerID)
+	r.afterEvents(events)
+}
+
+// absent reports whether playerID's turns should time out right away
+func (r *Room) absent(playerID string) bool {
+	return r.forfeited[playerID] || r.away[playerID]
+}
+
+// noteTimeout counts a timeout against playerID and marks them away once
+// they have timed out AwayAfter times in a row
+func (r *Room) noteTimeout(playerID string) {
+	if r.forfeited[playerID] || r.away[playerID] {
+		return
+	}
+	r.timeouts[playerID]++
+	if r.options.AwayAfter <= 0 || r.timeouts[playerID] < r.options.AwayAfter {
+		return
+	}
+
+	r.away[playerID] = true
+	fmt.Println("Player", playerID, "is away from game", r.ID)
+	r.notifyAll(protocol.PlayerAway{PlayerID: playerID})
+}
+
+// markPresent clears playerID's timeouts after they acted themselves
+func (r *Room) markPresent(playerID string) {
+	delete(r.timeouts, playerID)
+	if !r.away[playerID] {
+		return
+	}
+
+	delete(r.away, playerID)
+	fmt.Println("Player", playerID, "is back in game", r.ID)
+	r.notifyAll(protocol.PlayerBack{PlayerID: playerID})
+}
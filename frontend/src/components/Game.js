@@ -213,21 +213,9 @@ const Game = () => {
     sendMessage({ type: "acknowledgment", playerId });
   };
 
-  const handleCardClick = async (card, playerId) => {
-    const moveData = { card };
-    try {
-      const response = await fetch(
-        `http://localhost:8080/game/move?gameID=${gameState.GameID}&playerID=${playerId}`,
-        {
-          method: "POST",
-          headers: { "Content-Type": "application/json" },
-          body: JSON.stringify(moveData),
-        }
-      );
-      if (!response.ok) throw new Error("Network response was not ok");
-    } catch (error) {
-      log.error("Error sending move:", error);
-    }
+  const handleCardClick = (card) => {
+    // The server knows who we are from the connection
+    sendMessage({ type: "playcard", card });
   };
 
   const submitBid = () => {
@@ -336,7 +324,7 @@ const Game = () => {
             <Card
               key={index}
               suit={card.suit}
-              onClick={() => handleCardClick(card, userId)}
+              onClick={() => handleCardClick(card)}
             >
               {`${card.rank}${getSuitSymbol(card.suit)}`}
             </Card>
@@ -6,6 +6,7 @@ import useGameState from "../hooks/useGameState";
 import { useGameStateContext } from "../context/GameStateContext";
 import { usePlayerContext } from "../context/PlayerContext";
 import { createComponentLogger } from "../logger";
+import PrivateRoom from "./PrivateRoom";
 
 const log = createComponentLogger("JoinLobby", "info");
 
@@ -27,6 +28,9 @@ const JoinLobby = () => {
   } = useWebSocketContext();
   const navigate = useNavigate();
   const [lastReceivedMessage, setLastReceivedMessage] = useState("");
+  const [ticket, setTicket] = useState(null);
+  const [privateRoom, setPrivateRoom] = useState(null);
+  const [joinCode, setJoinCode] = useState("");
 
   // Function to join the lobby and retrieve the JWT token
   const joinLobby = async () => {
@@ -108,6 +112,23 @@ const JoinLobby = () => {
         case "message":
           setLastReceivedMessage(data);
           break;
+        case "queued":
+          setTicket(data);
+          break;
+        case "match_found":
+          log.debug("JoinLobby: Seated in", data.roomId, "seat", data.seat);
+          setTicket(null);
+          setPrivateRoom(null);
+          break;
+        case "private_room":
+          setTicket(null);
+          setPrivateRoom(data);
+          break;
+        case "ok":
+          break;
+        case "error":
+          log.error("Request refused:", data.code, data.message);
+          break;
         default:
           log.error("Unhandled message type:", type);
       }
@@ -124,14 +145,37 @@ const JoinLobby = () => {
 
   const startGame = async () => {
     try {
-      await axios.post("http://localhost:8080/game/start", null, {
+      // Queues us up, the match is announced over the socket
+      const response = await axios.post("http://localhost:8080/game/start", null, {
         headers: { Authorization: `Bearer ${token}` },
       });
+      setTicket(response.data);
     } catch (error) {
       console.error("Error starting the game:", error);
     }
   };
 
+  const leaveQueue = () => {
+    sendMessage({ type: "dequeue" });
+    setTicket(null);
+  };
+
+  // Private rooms are run over the socket, which announces every change
+  const createRoom = () => {
+    sendMessage({ type: "createroom", settings: { bots: true } });
+  };
+
+  const joinRoom = () => {
+    if (joinCode.trim()) {
+      sendMessage({ type: "joinroom", code: joinCode.trim().toUpperCase() });
+    }
+  };
+
+  const leaveRoom = () => {
+    sendMessage({ type: "leaveroom" });
+    setPrivateRoom(null);
+  };
+
   return (
     <div
       style={{
@@ -178,8 +222,33 @@ const JoinLobby = () => {
           ) : (
             <p>No players connected yet.</p>
           )}
-          {connectedPlayers && connectedPlayers.length >= 4 && (
-            <button onClick={startGame}>Start Game</button>
+          {privateRoom ? (
+            <PrivateRoom
+              room={privateRoom}
+              userId={userId}
+              sendMessage={sendMessage}
+              onLeave={leaveRoom}
+            />
+          ) : ticket ? (
+            <>
+              <p>Waiting for players, you are number {ticket.position} in the queue.</p>
+              <button onClick={leaveQueue}>Leave Queue</button>
+            </>
+          ) : (
+            <>
+              <button onClick={startGame}>Start Game</button>
+              <h2>Play with Friends</h2>
+              <button onClick={createRoom}>Create Private Room</button>
+              <div>
+                <input
+                  type="text"
+                  placeholder="Join code"
+                  value={joinCode}
+                  onChange={(e) => setJoinCode(e.target.value)}
+                />
+                <button onClick={joinRoom}>Join Room</button>
+              </div>
+            </>
           )}
         </>
       )}
@@ -0,0 +1,95 @@
+import React from "react";
+
+const VARIANTS = ["strict", "hook", "casual"];
+
+// PrivateRoom shows the room the player gathered in by join code. The host
+// configures and starts it; everyone sees the changes as they happen.
+const PrivateRoom = ({ room, userId, sendMessage, onLeave }) => {
+  const isHost = room.host === userId;
+  const settings = room.settings || {};
+
+  const configure = (changes) => {
+    sendMessage({ type: "configureroom", settings: { ...settings, ...changes } });
+  };
+  // Numbers are sent once typed in, blank keeps the default
+  const number = (value) => (value === "" ? 0 : parseInt(value, 10) || 0);
+
+  return (
+    <div style={{ border: "1px solid #ccc", padding: "10px", margin: "10px" }}>
+      <h2>Private Room {room.code}</h2>
+      <p>Share the code {room.code} with your friends so they can join.</p>
+      <h3>
+        Players ({room.players.length}/{room.seats})
+      </h3>
+      <ul>
+        {room.players.map((player) => (
+          <li key={player}>
+            {player}
+            {player === room.host && " (host)"}
+          </li>
+        ))}
+      </ul>
+
+      <div>
+        <label>Turn time (seconds): </label>
+        <input
+          type="number"
+          min="10"
+          max="300"
+          placeholder="100"
+          disabled={!isHost}
+          key={`turn-${settings.turnSeconds}`}
+          defaultValue={settings.turnSeconds || ""}
+          onBlur={(e) => configure({ turnSeconds: number(e.target.value) })}
+        />
+      </div>
+      <div>
+        <label>Deals: </label>
+        <input
+          type="number"
+          min="1"
+          max="20"
+          placeholder="5"
+          disabled={!isHost}
+          key={`deals-${settings.numDeals}`}
+          defaultValue={settings.numDeals || ""}
+          onBlur={(e) => configure({ numDeals: number(e.target.value) })}
+        />
+      </div>
+      <div>
+        <label>Rules: </label>
+        <select
+          disabled={!isHost}
+          value={settings.variant || "strict"}
+          onChange={(e) => configure({ variant: e.target.value })}
+        >
+          {VARIANTS.map((variant) => (
+            <option key={variant} value={variant}>
+              {variant}
+            </option>
+          ))}
+        </select>
+      </div>
+      <div>
+        <label>
+          <input
+            type="checkbox"
+            disabled={!isHost}
+            checked={!!settings.bots}
+            onChange={(e) => configure({ bots: e.target.checked })}
+          />
+          Fill empty seats with bots
+        </label>
+      </div>
+
+      {isHost ? (
+        <button onClick={() => sendMessage({ type: "startroom" })}>Start Game</button>
+      ) : (
+        <p>Waiting for {room.host} to start the game.</p>
+      )}
+      <button onClick={onLeave}>Leave Room</button>
+    </div>
+  );
+};
+
+export default PrivateRoom;
@@ -3,6 +3,9 @@ import { createComponentLogger } from "../logger";
 
 const log = createComponentLogger("useWebSocket", "debug");
 
+// Highest version of the server protocol this client understands
+const PROTOCOL_VERSION = 1;
+
 const useWebSocket = (url) => {
   const [ws, setWs] = useState(null);
   const [lastMessage, setLastMessage] = useState(null);
@@ -13,6 +16,7 @@ const useWebSocket = (url) => {
   const reconnectTimeoutId = useRef(null);
   const tokenRef = useRef(null);
   const isConnectingRef = useRef(false); // Track if currently connecting
+  const requestCounter = useRef(0); // Last request ID sent
 
   const connect = (token) => {
     // Prevent multiple connection attempts
@@ -31,7 +35,11 @@ const useWebSocket = (url) => {
 
     websocket.onopen = () => {
       log.info("WebSocket connected successfully");
-      const tokenMessage = JSON.stringify({ token: tokenRef.current });
+      const tokenMessage = JSON.stringify({
+        type: "hello",
+        token: tokenRef.current,
+        version: PROTOCOL_VERSION,
+      });
       websocket.send(tokenMessage);
       setIsConnected(true);
       reconnectAttempts.current = 0;
@@ -103,7 +111,11 @@ const useWebSocket = (url) => {
       log.debug("ws status when sending", ws.readyState);
       log.debug("Sending websocket message", message);
       if (ws) {
-        ws.send(JSON.stringify(message));
+        // The server echoes the request ID in its ok or error reply
+        requestCounter.current += 1;
+        const requestId = String(requestCounter.current);
+        ws.send(JSON.stringify({ ...message, requestId }));
+        return requestId;
       } else {
         console.error("WebSocket is not open. Attempting to reconnect.");
         connect(tokenRef.current);